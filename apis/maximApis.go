@@ -3,6 +3,7 @@ package apis
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"strings"
 )
@@ -15,6 +16,9 @@ type MaximError struct {
 // It contains an optional Error field which, if present, includes a message describing the error.
 type MaximApiResponse struct {
 	Error *MaximError `json:"error,omitempty"`
+	// StatusCode is the HTTP status of the response, or 0 when the request
+	// failed before a response was received.
+	StatusCode int `json:"-"`
 }
 
 func newMaximError(err error) *MaximError {
@@ -32,26 +36,14 @@ func newMaximError(err error) *MaximError {
 // Returns:
 //   - MaximApiResponse: The response from the API, which may contain an error message.
 func PushLogs(baseUrl, apiKey, repoId, logs string) MaximApiResponse {
+	return PushLogsWithClient(nil, baseUrl, apiKey, repoId, logs)
+}
+
+// PushLogsWithClient is PushLogs sending the request with the given client.
+// A nil client uses a default one.
+func PushLogsWithClient(client *http.Client, baseUrl, apiKey, repoId, logs string) MaximApiResponse {
 	url := fmt.Sprintf("%s/api/sdk/v3/log?id=%s", baseUrl, repoId)
-	client := &http.Client{}
-	req, err := http.NewRequest("POST", url, strings.NewReader(logs))
-	if err != nil {
-		return MaximApiResponse{Error: newMaximError(err)}
-	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("x-maxim-api-key", apiKey)
-	resp, err := client.Do(req)
-	if err != nil {
-		return MaximApiResponse{Error: newMaximError(err)}
-	}
-	defer resp.Body.Close()
-	var response MaximApiResponse
-	err = json.NewDecoder(resp.Body).Decode(&response)
-	if err != nil {
-		return MaximApiResponse{Error: newMaximError(err)}
-	}
-	return MaximApiResponse{}
+	return do(client, "POST", url, apiKey, strings.NewReader(logs))
 }
 
 // DoesLogRepoExists checks if a log repository exists.
@@ -64,9 +56,21 @@ func PushLogs(baseUrl, apiKey, repoId, logs string) MaximApiResponse {
 // Returns:
 //   - MaximApiResponse: The response from the API, which may contain an error message.
 func DoesLogRepoExists(baseUrl, apiKey, repoId string) MaximApiResponse {
+	return DoesLogRepoExistsWithClient(nil, baseUrl, apiKey, repoId)
+}
+
+// DoesLogRepoExistsWithClient is DoesLogRepoExists sending the request with
+// the given client. A nil client uses a default one.
+func DoesLogRepoExistsWithClient(client *http.Client, baseUrl, apiKey, repoId string) MaximApiResponse {
 	url := fmt.Sprintf("%s/api/sdk/v3/log-repositories?loggerId=%s", baseUrl, repoId)
-	client := &http.Client{}
-	req, err := http.NewRequest("GET", url, nil)
+	return do(client, "GET", url, apiKey, nil)
+}
+
+func do(client *http.Client, method, url, apiKey string, body io.Reader) MaximApiResponse {
+	if client == nil {
+		client = &http.Client{}
+	}
+	req, err := http.NewRequest(method, url, body)
 	if err != nil {
 		return MaximApiResponse{Error: newMaximError(err)}
 	}
@@ -80,8 +84,15 @@ func DoesLogRepoExists(baseUrl, apiKey, repoId string) MaximApiResponse {
 	defer resp.Body.Close()
 	var response MaximApiResponse
 	err = json.NewDecoder(resp.Body).Decode(&response)
+	response.StatusCode = resp.StatusCode
+	if response.Error != nil {
+		return response
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return MaximApiResponse{Error: &MaximError{Message: fmt.Sprintf("unexpected status %s", resp.Status)}, StatusCode: resp.StatusCode}
+	}
 	if err != nil {
-		return MaximApiResponse{Error: newMaximError(err)}
+		return MaximApiResponse{Error: newMaximError(err), StatusCode: resp.StatusCode}
 	}
-	return MaximApiResponse{}
+	return response
 }
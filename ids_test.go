@@ -0,0 +1,27 @@
+package maxim_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/maximhq/maxim-go"
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-([47])[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func TestNewID(t *testing.T) {
+	previous := ""
+	for i := 0; i < 100; i++ {
+		id := maxim.NewID()
+		if m := uuidPattern.FindStringSubmatch(id); m == nil || m[1] != "7" {
+			t.Fatalf("expected a version 7 UUID, got %q", id)
+		}
+		if id[:13] < previous {
+			t.Fatalf("expected time-ordered ids, got %q after %q", id, previous)
+		}
+		previous = id[:13]
+	}
+	if m := uuidPattern.FindStringSubmatch(maxim.NewUUIDv4()); m == nil || m[1] != "4" {
+		t.Error("expected a version 4 UUID")
+	}
+}
@@ -0,0 +1,39 @@
+package internal
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"time"
+)
+
+// NewUUIDv7 returns a time-ordered (version 7) UUID.
+func NewUUIDv7() string {
+	id := randomBytes()
+	var ms [8]byte
+	binary.BigEndian.PutUint64(ms[:], uint64(time.Now().UnixMilli()))
+	copy(id[0:6], ms[2:8])
+	id[6] = (id[6] & 0x0f) | 0x70
+	id[8] = (id[8] & 0x3f) | 0x80
+	return format(id)
+}
+
+// NewUUIDv4 returns a random (version 4) UUID.
+func NewUUIDv4() string {
+	id := randomBytes()
+	id[6] = (id[6] & 0x0f) | 0x40
+	id[8] = (id[8] & 0x3f) | 0x80
+	return format(id)
+}
+
+func randomBytes() []byte {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		binary.BigEndian.PutUint64(id[8:], uint64(time.Now().UnixNano()))
+	}
+	return id
+}
+
+func format(id []byte) string {
+	return fmt.Sprintf("%x-%x-%x-%x-%x", id[0:4], id[4:6], id[6:8], id[8:10], id[10:])
+}
@@ -1,10 +1,16 @@
 package internal
 
 import (
-	"log"
+	"log/slog"
 	"os"
 )
 
-func NewDebugLogger() *log.Logger {
-	return log.New(os.Stdout, "[MaximSDK][Debug]: ", log.Ldate|log.Ltime)
+// NewLogger returns the SDK logger used when none is injected. Debug mode
+// logs everything to stdout; otherwise only warnings and errors are written,
+// to stderr.
+func NewLogger(debug bool) *slog.Logger {
+	if debug {
+		return slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug})).With("sdk", "maxim")
+	}
+	return slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn})).With("sdk", "maxim")
 }
@@ -0,0 +1,4 @@
+package internal
+
+// SDKVersion is the version of the Maxim Go SDK, reported in resource tags.
+const SDKVersion = "0.1.0"
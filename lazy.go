@@ -0,0 +1,98 @@
+package maxim
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/maximhq/maxim-go/internal"
+	"github.com/maximhq/maxim-go/logging"
+)
+
+const (
+	defaultRepoCheckRetryInterval = 5 * time.Second
+	maxRepoCheckRetryInterval     = time.Minute
+)
+
+// repoError is a failed repository check. It wraps ErrRepoNotFound.
+type repoError struct {
+	repoId  string
+	message string
+	// status is the HTTP status of the check, 0 when the API could not be
+	// reached.
+	status int
+}
+
+func (e *repoError) Error() string {
+	return fmt.Sprintf("%s %s: %s", ErrRepoNotFound, e.repoId, e.message)
+}
+
+func (e *repoError) Unwrap() error {
+	return ErrRepoNotFound
+}
+
+// isTransient reports whether a repository check failed because the API
+// could not answer it, or asked to come back later, rather than because the
+// repository was rejected.
+func isTransient(err error) bool {
+	var repoErr *repoError
+	if !errors.As(err, &repoErr) {
+		return false
+	}
+	switch repoErr.status {
+	case 0, http.StatusRequestTimeout, http.StatusTooManyRequests:
+		return true
+	}
+	return repoErr.status >= 500
+}
+
+// verifyInBackground retries the check of a log repository until it
+// succeeds, then resumes the pushes of its logger. It gives up when the SDK
+// is cleaned up, the logger removed, or the API rejects the repository, in
+// which case the logger is removed and its logs are dropped from then on.
+func (m *Maxim) verifyInBackground(l *logging.Logger, repoId string, onError func(error)) {
+	delay := m.retryInterval
+	if delay <= 0 {
+		delay = defaultRepoCheckRetryInterval
+	}
+	for {
+		select {
+		case <-m.done:
+			return
+		case <-time.After(delay):
+		}
+		if m.Loggers()[repoId] != l {
+			return
+		}
+		if err := m.verifyRepo(repoId); err != nil {
+			m.reportRepoError(onError, err)
+			if !isTransient(err) {
+				l.DiscardPushes()
+				m.removeLogger(repoId, l)
+				return
+			}
+			delay = min(delay*2, maxRepoCheckRetryInterval)
+			continue
+		}
+		m.mutex.Lock()
+		m.verified[repoId] = true
+		m.mutex.Unlock()
+		l.ResumePushes()
+		return
+	}
+}
+
+// reportRepoError hands a failed repository check to the logger's OnError,
+// or logs it when there is none.
+func (m *Maxim) reportRepoError(onError func(error), err error) {
+	if onError != nil {
+		onError(err)
+		return
+	}
+	logger := m.logger
+	if logger == nil {
+		logger = internal.NewLogger(m.debug)
+	}
+	logger.Warn("log repository not verified, spooling logs to disk", "error", err)
+}
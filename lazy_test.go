@@ -0,0 +1,130 @@
+package maxim_test
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/maximhq/maxim-go"
+	"github.com/maximhq/maxim-go/logging"
+)
+
+func TestLazyRepoCheck(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+	server := newFakeMaxim(t)
+	server.repoStatus.Store(http.StatusServiceUnavailable)
+	mx, err := maxim.New(maxim.WithAPIKey("key"), maxim.WithBaseURL(server.URL), maxim.WithLazyRepoCheck(10*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mx.Cleanup()
+	var repoErrors atomic.Int32
+	logger, err := mx.GetLogger(&logging.LoggerConfig{
+		Id: "repo-1",
+		OnError: func(err error) {
+			if !errors.Is(err, maxim.ErrRepoNotFound) {
+				t.Errorf("unexpected error %v", err)
+			}
+			repoErrors.Add(1)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Trace(&logging.TraceConfig{Id: "trace-1"}).End()
+	logger.Flush()
+	if n := server.pushes.Load(); n != 0 {
+		t.Fatalf("expected logs to be spooled while the repository is not verified, got %d pushes", n)
+	}
+
+	waitFor(t, func() bool { return repoErrors.Load() >= 2 })
+	server.repoStatus.Store(http.StatusOK)
+	waitFor(t, func() bool { return server.pushes.Load() == 1 })
+}
+
+func TestGetLoggerFailsWithoutLazyRepoCheck(t *testing.T) {
+	server := newFakeMaxim(t)
+	server.repoStatus.Store(http.StatusServiceUnavailable)
+	mx, err := maxim.New(maxim.WithAPIKey("key"), maxim.WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := mx.GetLogger(&logging.LoggerConfig{Id: "repo-1"}); !errors.Is(err, maxim.ErrRepoNotFound) {
+		t.Errorf("expected ErrRepoNotFound, got %v", err)
+	}
+}
+
+func TestLazyRepoCheckReturnsRejections(t *testing.T) {
+	server := newFakeMaxim(t)
+	server.repoStatus.Store(http.StatusNotFound)
+	mx, err := maxim.New(maxim.WithAPIKey("key"), maxim.WithBaseURL(server.URL), maxim.WithLazyRepoCheck(10*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mx.Cleanup()
+	if _, err := mx.GetLogger(&logging.LoggerConfig{Id: "repo-1"}); !errors.Is(err, maxim.ErrRepoNotFound) {
+		t.Errorf("expected ErrRepoNotFound, got %v", err)
+	}
+	if n := len(mx.Loggers()); n != 0 {
+		t.Errorf("expected no logger for a rejected repository, got %d", n)
+	}
+}
+
+func TestLazyRepoCheckStopsOnRejection(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+	server := newFakeMaxim(t)
+	server.repoStatus.Store(http.StatusTooManyRequests)
+	mx, err := maxim.New(maxim.WithAPIKey("key"), maxim.WithBaseURL(server.URL), maxim.WithLazyRepoCheck(5*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mx.Cleanup()
+	var rejected atomic.Bool
+	var discarded atomic.Int32
+	logger, err := mx.GetLogger(&logging.LoggerConfig{
+		Id:      "repo-1",
+		OnError: func(err error) { rejected.Store(strings.Contains(err.Error(), "403")) },
+		OnDrop: func(n int, reason string) {
+			if reason == logging.DropReasonDiscarded {
+				discarded.Add(int32(n))
+			}
+		},
+	})
+	if err != nil {
+		t.Fatalf("rate limited check was not deferred: %v", err)
+	}
+	server.repoStatus.Store(http.StatusForbidden)
+	waitFor(t, func() bool { return rejected.Load() && len(mx.Loggers()) == 0 })
+	checks := server.repoChecks.Load()
+	spooled, _ := os.ReadDir(filepath.Join(tmp, "maxim-sdk", "repo-1", "maxim-logs"))
+
+	logger.Trace(&logging.TraceConfig{Id: "trace-1"}).End()
+	logger.Flush()
+	time.Sleep(50 * time.Millisecond)
+	if n := server.repoChecks.Load(); n != checks {
+		t.Errorf("repository checks went on after a rejection: %d, then %d", checks, n)
+	}
+	if files, _ := os.ReadDir(filepath.Join(tmp, "maxim-sdk", "repo-1", "maxim-logs")); len(files) != len(spooled) {
+		t.Errorf("logs were spooled after the rejection: %d files, then %d", len(spooled), len(files))
+	}
+	if discarded.Load() != 2 {
+		t.Errorf("expected the logs flushed after the rejection to be dropped, got %d", discarded.Load())
+	}
+}
+
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met before the deadline")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -1,14 +1,20 @@
 package logging
 
 import (
+	"fmt"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
 type baseConfig struct {
-	Id     string             `json:"id"`
-	SpanId *string            `json:"spanId,omitempty"`
-	Name   *string            `json:"name,omitempty"`
-	Tags   *map[string]string `json:"tags,omitempty"`
+	Id             string                 `json:"id"`
+	SpanId         *string                `json:"spanId,omitempty"`
+	Name           *string                `json:"name,omitempty"`
+	Tags           *map[string]string     `json:"tags,omitempty"`
+	StartTimestamp *time.Time             `json:"startTimestamp,omitempty"`
+	EndTimestamp   *time.Time             `json:"endTimestamp,omitempty"`
+	Metadata       map[string]interface{} `json:"metadata,omitempty"`
 }
 
 type base struct {
@@ -16,26 +22,106 @@ type base struct {
 	id             string
 	name           *string
 	spanId         *string
-	tags           *map[string]string
 	startTimestamp time.Time
-	endTimestamp   *time.Time
-	writer         *writer
+	// stateMutex guards tags, metadata and endTimestamp, which leak
+	// detection may update from the flush loop.
+	stateMutex   sync.Mutex
+	tags         *map[string]string
+	metadata     map[string]interface{}
+	endTimestamp *time.Time
+	// endAt is the configured end timestamp, used by End.
+	endAt  *time.Time
+	writer *writer
+	// unsampledTrace is set on the entities of a trace dropped by the
+	// Sampler, which commit nothing.
+	unsampledTrace string
+	ended          atomic.Bool
+	// children are the open entities added through this handle, ended with
+	// it under LoggerConfig.CascadeEnd. Children remove themselves when they
+	// end, through parent.
+	childMutex sync.Mutex
+	children   map[*base]struct{}
+	parent     atomic.Pointer[base]
 }
 
 func newBase(e Entity, id string, c *baseConfig, w *writer) *base {
+	startTimestamp := w.now()
+	if c.StartTimestamp != nil {
+		startTimestamp = c.StartTimestamp.UTC()
+	}
 	return &base{
 		entity:         e,
 		id:             id,
 		name:           c.Name,
 		spanId:         c.SpanId,
-		tags:           c.Tags,
-		startTimestamp: utcNow(),
+		tags:           mergeTags(w.config.DefaultTags, c.Tags),
+		startTimestamp: startTimestamp,
+		endAt:          c.EndTimestamp,
+		metadata:       checkedMetadata(w, e, id, c.Metadata),
 		writer:         w,
 	}
 }
 
 func (b *base) commit(action string, data interface{}) {
-	b.writer.commit(newCommitLog(b.entity, b.id, action, data))
+	if !b.accept(action) {
+		return
+	}
+	b.send(action, data)
+}
+
+// accept reports whether the entity still takes the action, applying the
+// EndedPolicy otherwise. Feedback is accepted after the end, since it
+// usually comes later.
+func (b *base) accept(action string) bool {
+	if action == "add-feedback" {
+		return true
+	}
+	if action == "end" && b.ended.CompareAndSwap(false, true) {
+		if b.writer.leaks != nil {
+			b.writer.leaks.untrack(b.entity, b.id)
+		}
+		if parent := b.parent.Load(); parent != nil {
+			parent.removeChild(b)
+		}
+		return true
+	}
+	if !b.ended.Load() {
+		return true
+	}
+	switch b.writer.config.EndedPolicy {
+	case EndedPolicyIgnore:
+	case EndedPolicyError:
+		b.writer.reportError(fmt.Errorf("%w: %s %s, dropping %q", ErrEntityEnded, b.entity, b.id, action))
+	default:
+		b.writer.logger.Warn("dropping update of an ended entity", "entity", b.entity, "entityId", b.id, "action", action)
+	}
+	return false
+}
+
+func (b *base) send(action string, data interface{}) {
+	if b.unsampledTrace != "" {
+		if action == "end" {
+			b.writer.forgetUnsampled(b.id)
+		}
+		return
+	}
+	b.writer.commit(NewCommitLog(b.entity, b.id, action, data))
+}
+
+// skipIfUnsampled marks the new entity unsampled when it belongs to the
+// given unsampled trace, and reports whether it did, so callers can return
+// before committing anything. Otherwise the entity is registered with the
+// leak detector: unsampled entities are never registered anywhere.
+func (b *base) skipIfUnsampled(unsampledTrace string) bool {
+	if unsampledTrace == "" {
+		if b.writer.leaks != nil {
+			b.writer.leaks.track(b)
+		}
+		return false
+	}
+	b.unsampledTrace = unsampledTrace
+	b.writer.markUnsampled(b.id, unsampledTrace)
+	return true
 }
 
 func (b *base) Id() string {
@@ -43,22 +129,132 @@ func (b *base) Id() string {
 }
 
 func (b *base) AddTag(key, value string) {
+	b.stateMutex.Lock()
 	if b.tags == nil {
 		b.tags = &map[string]string{}
 	}
 	(*b.tags)[key] = value
+	tags := copyTags(*b.tags)
+	b.stateMutex.Unlock()
 	b.commit("update", map[string]interface{}{
-		"tags": *b.tags,
+		"tags": tags,
 	})
 }
 
+// SetMetadata sets a metadata value, which unlike tags keeps its JSON type:
+// numbers, booleans, and nested objects or arrays. Values that cannot be
+// encoded as JSON are reported to LoggerConfig.OnError and dropped.
+func (b *base) SetMetadata(key string, value interface{}) {
+	if err := checkMetadata(b.entity, b.id, key, value); err != nil {
+		b.writer.reportError(err)
+		return
+	}
+	b.stateMutex.Lock()
+	if b.metadata == nil {
+		b.metadata = map[string]interface{}{}
+	}
+	b.metadata[key] = value
+	metadata := copyMetadata(b.metadata)
+	b.stateMutex.Unlock()
+	b.commit("update", map[string]interface{}{
+		"metadata": metadata,
+	})
+}
+
+// End ends the entity at the EndTimestamp of its config if set, and now
+// otherwise.
 func (b *base) End() {
-	b.endTimestamp = utcNowPtr()
-	b.commit("end", map[string]interface{}{
-		"endTimestamp": b.endTimestamp,
+	b.EndAt(b.endTime())
+}
+
+func (b *base) endTime() time.Time {
+	if b.endAt != nil {
+		return *b.endAt
+	}
+	return b.writer.now()
+}
+
+// EndAt ends the entity with an explicit end timestamp, e.g. when the
+// entity is recorded after the fact from another tracing system.
+func (b *base) EndAt(t time.Time) {
+	if !b.accept("end") {
+		return
+	}
+	endTimestamp := t.UTC()
+	b.endChildren(endTimestamp)
+	b.setEndTimestamp(endTimestamp)
+	b.send("end", map[string]interface{}{
+		"endTimestamp": endTimestamp,
 	})
 }
 
+func (b *base) setEndTimestamp(t time.Time) {
+	b.stateMutex.Lock()
+	b.endTimestamp = &t
+	b.stateMutex.Unlock()
+}
+
+// addChild records an entity added through this handle, when ending
+// cascades.
+func (b *base) addChild(child *base) {
+	if !b.writer.config.CascadeEnd {
+		return
+	}
+	child.parent.Store(b)
+	b.childMutex.Lock()
+	// The child may have been ended already, e.g. by leak detection.
+	if !child.Ended() {
+		if b.children == nil {
+			b.children = map[*base]struct{}{}
+		}
+		b.children[child] = struct{}{}
+	}
+	b.childMutex.Unlock()
+}
+
+func (b *base) removeChild(child *base) {
+	b.childMutex.Lock()
+	delete(b.children, child)
+	b.childMutex.Unlock()
+}
+
+// endChildren ends the children that are still open, and theirs.
+func (b *base) endChildren(t time.Time) {
+	b.childMutex.Lock()
+	children := b.children
+	b.children = nil
+	b.childMutex.Unlock()
+	for child := range children {
+		if !child.Ended() {
+			child.EndAt(t)
+		}
+	}
+}
+
+// recordError records the error on the entity and marks it as failed.
+func (b *base) recordError(err error) {
+	if err == nil {
+		return
+	}
+	b.commit("update", errorData(b.writer, err))
+}
+
+// Ended reports whether the entity ended.
+func (b *base) Ended() bool {
+	return b.ended.Load()
+}
+
+// Duration returns how long the entity lasted, or has lasted so far if it
+// has not ended.
+func (b *base) Duration() time.Duration {
+	b.stateMutex.Lock()
+	defer b.stateMutex.Unlock()
+	if b.endTimestamp != nil {
+		return b.endTimestamp.Sub(b.startTimestamp)
+	}
+	return b.writer.now().Sub(b.startTimestamp)
+}
+
 func (b *base) data() map[string]interface{} {
 	data := map[string]interface{}{
 		"startTimestamp": b.startTimestamp,
@@ -69,8 +265,13 @@ func (b *base) data() map[string]interface{} {
 	if b.spanId != nil {
 		data["spanId"] = *b.spanId
 	}
+	b.stateMutex.Lock()
+	defer b.stateMutex.Unlock()
 	if b.tags != nil {
-		data["tags"] = *b.tags
+		data["tags"] = copyTags(*b.tags)
+	}
+	if len(b.metadata) > 0 {
+		data["metadata"] = copyMetadata(b.metadata)
 	}
 	if b.endTimestamp != nil {
 		data["endTimestamp"] = *b.endTimestamp
@@ -81,7 +282,7 @@ func (b *base) data() map[string]interface{} {
 // Static methods
 
 func addTag(w *writer, entity Entity, id, key, value string) {
-	w.commit(newCommitLog(entity, id, "update", map[string]interface{}{
+	w.commit(NewCommitLog(entity, id, "update", map[string]interface{}{
 		"tags": map[string]string{
 			key: value,
 		},
@@ -92,20 +293,39 @@ func addEvent(w *writer, entity Entity, entityId, eId, event string, tags *map[s
 	eventData := map[string]interface{}{
 		"id":        eId,
 		"name":      event,
-		"timestamp": utcNow(),
+		"timestamp": w.now(),
 	}
 	if tags != nil {
 		eventData["tags"] = tags
 	}
-	w.commit(newCommitLog(entity, entityId, "add-event", eventData))
+	w.commit(NewCommitLog(entity, entityId, "add-event", eventData))
 }
 
 func addFeedback(w *writer, entity Entity, id string, feedback *Feedback) {
-	w.commit(newCommitLog(entity, id, "add-feedback", feedback))
+	w.commit(NewCommitLog(entity, id, "add-feedback", feedback))
+}
+
+func setMetadata(w *writer, entity Entity, id, key string, value interface{}) {
+	if err := checkMetadata(entity, id, key, value); err != nil {
+		w.reportError(err)
+		return
+	}
+	w.commit(NewCommitLog(entity, id, "update", map[string]interface{}{
+		"metadata": map[string]interface{}{
+			key: value,
+		},
+	}))
+}
+
+func setError(w *writer, entity Entity, id string, err error) {
+	if err == nil {
+		return
+	}
+	w.commit(NewCommitLog(entity, id, "update", errorData(w, err)))
 }
 
 func end(w *writer, entity Entity, id string) {
-	w.commit(newCommitLog(entity, id, "end", map[string]interface{}{
-		"endTimestamp": utcNow(),
+	w.commit(NewCommitLog(entity, id, "end", map[string]interface{}{
+		"endTimestamp": w.now(),
 	}))
 }
@@ -0,0 +1,118 @@
+package logging
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEndedEntityRejectsUpdates(t *testing.T) {
+	var errs []error
+	l, ts := newTestLogger(t, &LoggerConfig{
+		EndedPolicy: EndedPolicyError,
+		OnError:     func(err error) { errs = append(errs, err) },
+	})
+	trace := l.Trace(&TraceConfig{Id: "trace-1"})
+	trace.End()
+	trace.SetOutput("late")
+	trace.AddTag("late", "true")
+	trace.End()
+	trace.SetFeedback(&Feedback{Score: 1})
+	l.Flush()
+
+	if !trace.Ended() {
+		t.Error("expected the trace to be ended")
+	}
+	if len(errs) != 3 {
+		t.Fatalf("expected 3 errors, got %v", errs)
+	}
+	for _, err := range errs {
+		if !errors.Is(err, ErrEntityEnded) {
+			t.Errorf("expected ErrEntityEnded, got %v", err)
+		}
+	}
+	logs := ts.logs()
+	if n := strings.Count(logs, "action=end"); n != 1 {
+		t.Errorf("expected a single end commit, got %d\n%s", n, logs)
+	}
+	if strings.Contains(logs, "late") {
+		t.Errorf("updates after the end were committed\n%s", logs)
+	}
+	if !strings.Contains(logs, "action=add-feedback") {
+		t.Errorf("feedback after the end was dropped\n%s", logs)
+	}
+}
+
+func TestEntityDuration(t *testing.T) {
+	l, _ := newTestLogger(t, nil)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	span := l.Trace(&TraceConfig{Id: "trace-1"}).AddSpan(&SpanConfig{Id: "span-1", StartTimestamp: &start})
+	if span.Ended() {
+		t.Error("expected the span to be open")
+	}
+	span.EndAt(start.Add(3 * time.Second))
+	span.EndAt(start.Add(time.Hour))
+	if d := span.Duration(); d != 3*time.Second {
+		t.Errorf("expected 3s, got %s", d)
+	}
+}
+
+func TestCascadeEnd(t *testing.T) {
+	var errs []error
+	l, ts := newTestLogger(t, &LoggerConfig{
+		CascadeEnd:  true,
+		EndedPolicy: EndedPolicyError,
+		OnError:     func(err error) { errs = append(errs, err) },
+	})
+	trace := l.Trace(&TraceConfig{Id: "trace-1"})
+	span := trace.AddSpan(&SpanConfig{Id: "span-1"})
+	subSpan := span.AddSubSpan(&SpanConfig{Id: "span-2"})
+	generation := span.AddGeneration(&GenerationConfig{Id: "generation-1"})
+	generation.End()
+	retrieval := trace.AddRetrieval(&RetrievalConfig{Id: "retrieval-1"})
+	end := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	trace.EndAt(end)
+	l.Flush()
+
+	for _, b := range []*base{span.base, subSpan.base, retrieval.base} {
+		if !b.Ended() || !b.endTimestamp.Equal(end) {
+			t.Errorf("expected %s %s to end with the trace", b.entity, b.id)
+		}
+	}
+	if len(errs) != 0 {
+		t.Errorf("ended children were ended again: %v", errs)
+	}
+	if n := strings.Count(ts.logs(), "action=end"); n != 5 {
+		t.Errorf("expected 5 end commits, got %d\n%s", n, ts.logs())
+	}
+}
+
+func TestCascadeEndForgetsEndedChildren(t *testing.T) {
+	l, _ := newTestLogger(t, &LoggerConfig{CascadeEnd: true})
+	trace := l.Trace(&TraceConfig{Id: "trace-1"})
+	for i := 0; i < 10; i++ {
+		span := trace.AddSpan(&SpanConfig{})
+		span.AddGeneration(&GenerationConfig{}).End()
+		span.End()
+		trace.AddRetrieval(&RetrievalConfig{}).SetOutput([]string{"doc"})
+	}
+	open := trace.AddSpan(&SpanConfig{Id: "span-open"})
+	if n := len(trace.children); n != 1 {
+		t.Errorf("expected only the open span to be kept, got %d children", n)
+	}
+	trace.End()
+	if !open.Ended() {
+		t.Error("expected the open span to end with the trace")
+	}
+}
+
+func TestEndDoesNotCascadeByDefault(t *testing.T) {
+	l, _ := newTestLogger(t, nil)
+	trace := l.Trace(&TraceConfig{Id: "trace-1"})
+	span := trace.AddSpan(&SpanConfig{Id: "span-1"})
+	trace.End()
+	if span.Ended() {
+		t.Error("expected the span to stay open")
+	}
+}
@@ -0,0 +1,16 @@
+package logging
+
+import "time"
+
+// Clock is the source of the timestamps of a Logger, e.g. a fake one in
+// tests.
+type Clock interface {
+	Now() time.Time
+}
+
+// ClockFunc adapts a function to a Clock.
+type ClockFunc func() time.Time
+
+func (f ClockFunc) Now() time.Time {
+	return f()
+}
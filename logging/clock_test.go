@@ -0,0 +1,43 @@
+package logging
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClockAndExplicitTimestamps(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	l, ts := newTestLogger(t, &LoggerConfig{Clock: ClockFunc(func() time.Time { return now })})
+	trace := l.Trace(&TraceConfig{Id: "trace-1"})
+	now = now.Add(time.Second)
+
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(2 * time.Second)
+	span := trace.AddSpan(&SpanConfig{Id: "span-1", StartTimestamp: &start, EndTimestamp: &end})
+	retrieval := span.AddRetrieval(&RetrievalConfig{Id: "retrieval-1", StartTimestamp: &start, EndTimestamp: &end})
+	retrieval.SetOutput([]string{"doc"})
+	span.End()
+	trace.End()
+	l.EndSpan("span-2")
+	l.Flush()
+
+	if d := trace.Duration(); d != time.Second {
+		t.Errorf("expected the trace to last 1s on the clock, got %s", d)
+	}
+	if d := span.Duration(); d != 2*time.Second {
+		t.Errorf("expected the span to last 2s, got %s", d)
+	}
+	logs := ts.logs()
+	for _, want := range []string{
+		`trace{id=trace-1,action=create,data={"id":"trace-1","startTimestamp":"2024-05-01T12:00:00Z"}}`,
+		`trace{id=trace-1,action=end,data={"endTimestamp":"2024-05-01T12:00:01Z"}}`,
+		`span{id=span-1,action=end,data={"endTimestamp":"2023-01-01T00:00:02Z"}}`,
+		`retrieval{id=retrieval-1,action=end,data={"docs":["doc"],"endTimestamp":"2023-01-01T00:00:02Z"}}`,
+		`span{id=span-2,action=end,data={"endTimestamp":"2024-05-01T12:00:01Z"}}`,
+	} {
+		if !strings.Contains(logs, want) {
+			t.Errorf("pushed logs missing %q\n%s", want, logs)
+		}
+	}
+}
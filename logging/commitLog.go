@@ -23,10 +23,14 @@ type CommitLog struct {
 	entityID string
 	action   string
 	data     interface{}
+	// then are commits of the pipeline sent right after this one, e.g. the
+	// truncation tags of the payload limiter. They go through the
+	// processors following the one that added them.
+	then []*CommitLog
 }
 
 // NewCommitLog creates a new CommitLog instance
-func newCommitLog(entity Entity, entityID, action string, data interface{}) *CommitLog {
+func NewCommitLog(entity Entity, entityID, action string, data interface{}) *CommitLog {
 	return &CommitLog{
 		entity:   entity,
 		entityID: entityID,
@@ -35,18 +39,52 @@ func newCommitLog(entity Entity, entityID, action string, data interface{}) *Com
 	}
 }
 
+// Entity returns the type of entity the log applies to.
+func (cl *CommitLog) Entity() Entity {
+	return cl.entity
+}
+
+// EntityId returns the id of the entity the log applies to.
+func (cl *CommitLog) EntityId() string {
+	return cl.entityID
+}
+
+// Action returns the action of the log, e.g. "create", "update" or "end".
+func (cl *CommitLog) Action() string {
+	return cl.action
+}
+
+// Data returns the payload of the log. It is usually a
+// map[string]interface{} and may be shared with entity handles, so it must
+// not be modified in place; use WithData to rewrite it.
+func (cl *CommitLog) Data() interface{} {
+	return cl.data
+}
+
+// WithData returns a copy of the log with its payload replaced.
+func (cl *CommitLog) WithData(data interface{}) *CommitLog {
+	return NewCommitLog(cl.entity, cl.entityID, cl.action, data)
+}
+
 // Serialize converts the CommitLog to a string representation
 func (cl *CommitLog) Serialize() string {
-	var dataJSON []byte
-	var err error
+	serialized, err := cl.serialize()
+	if err != nil {
+		return fmt.Sprintf("%s{id=%s,action=%s,data={}}", cl.entity, cl.entityID, cl.action)
+	}
+	return serialized
+}
 
-	if cl.data == nil {
-		dataJSON = []byte("{}")
-	} else {
+// serialize is Serialize reporting data that cannot be encoded instead of
+// replacing it with an empty object.
+func (cl *CommitLog) serialize() (string, error) {
+	dataJSON := []byte("{}")
+	if cl.data != nil {
+		var err error
 		dataJSON, err = json.Marshal(cl.data)
 		if err != nil {
-			dataJSON = []byte("{}")
+			return "", fmt.Errorf("%w: %s %s on %s: %v", ErrSerializationFailed, cl.entity, cl.entityID, cl.action, err)
 		}
 	}
-	return fmt.Sprintf("%s{id=%s,action=%s,data=%s}", cl.entity, cl.entityID, cl.action, string(dataJSON))
+	return fmt.Sprintf("%s{id=%s,action=%s,data=%s}", cl.entity, cl.entityID, cl.action, string(dataJSON)), nil
 }
@@ -0,0 +1,56 @@
+package logging
+
+import "context"
+
+type contextKey int
+
+const (
+	traceContextKey contextKey = iota
+	spanContextKey
+	generationContextKey
+)
+
+// ContextWithTrace returns a copy of ctx carrying the trace.
+func ContextWithTrace(ctx context.Context, t *Trace) context.Context {
+	return context.WithValue(ctx, traceContextKey, t)
+}
+
+// TraceFromContext returns the trace carried by ctx, or nil.
+func TraceFromContext(ctx context.Context) *Trace {
+	t, _ := ctx.Value(traceContextKey).(*Trace)
+	return t
+}
+
+// ContextWithSpan returns a copy of ctx carrying the span.
+func ContextWithSpan(ctx context.Context, s *Span) context.Context {
+	return context.WithValue(ctx, spanContextKey, s)
+}
+
+// SpanFromContext returns the span carried by ctx, or nil.
+func SpanFromContext(ctx context.Context) *Span {
+	s, _ := ctx.Value(spanContextKey).(*Span)
+	return s
+}
+
+// ContextWithGeneration returns a copy of ctx carrying the generation.
+func ContextWithGeneration(ctx context.Context, g *Generation) context.Context {
+	return context.WithValue(ctx, generationContextKey, g)
+}
+
+// GenerationFromContext returns the generation carried by ctx, or nil.
+func GenerationFromContext(ctx context.Context) *Generation {
+	g, _ := ctx.Value(generationContextKey).(*Generation)
+	return g
+}
+
+// eventEmitterFromContext returns the innermost entity of ctx that accepts
+// events: the span if there is one, the trace otherwise.
+func eventEmitterFromContext(ctx context.Context) *eventEmitter {
+	if s := SpanFromContext(ctx); s != nil {
+		return s.eventEmitter
+	}
+	if t := TraceFromContext(ctx); t != nil {
+		return t.eventEmitter
+	}
+	return nil
+}
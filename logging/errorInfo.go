@@ -0,0 +1,72 @@
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+)
+
+// ErrorInfo is what SetError records of an error.
+type ErrorInfo struct {
+	Message string `json:"message"`
+	// Type is the Go type of the error, e.g. "*net.OpError".
+	Type string `json:"type"`
+	// Chain holds the errors wrapped by it, outermost first.
+	Chain []WrappedError `json:"chain,omitempty"`
+	// Stack is where the error was recorded, when
+	// LoggerConfig.RecordErrorStacks is set.
+	Stack string `json:"stack,omitempty"`
+}
+
+// WrappedError is an error found in the chain of an ErrorInfo.
+type WrappedError struct {
+	Message string `json:"message"`
+	Type    string `json:"type"`
+}
+
+func newErrorInfo(err error, withStack bool) *ErrorInfo {
+	info := &ErrorInfo{
+		Message: err.Error(),
+		Type:    fmt.Sprintf("%T", err),
+		Chain:   unwrapChain(err),
+	}
+	if withStack {
+		info.Stack = string(debug.Stack())
+	}
+	return info
+}
+
+// unwrapChain returns the errors wrapped by err, depth first, including
+// the branches of joined errors.
+func unwrapChain(err error) []WrappedError {
+	var chain []WrappedError
+	var walk func(err error)
+	walk = func(err error) {
+		var wrapped []error
+		switch e := err.(type) {
+		case interface{ Unwrap() []error }:
+			wrapped = e.Unwrap()
+		default:
+			if next := errors.Unwrap(err); next != nil {
+				wrapped = []error{next}
+			}
+		}
+		for _, w := range wrapped {
+			if w == nil {
+				continue
+			}
+			chain = append(chain, WrappedError{Message: w.Error(), Type: fmt.Sprintf("%T", w)})
+			walk(w)
+		}
+	}
+	walk(err)
+	return chain
+}
+
+// errorData is the commit data marking an entity as failed with err.
+func errorData(w *writer, err error) map[string]interface{} {
+	return map[string]interface{}{
+		"error":  newErrorInfo(err, w.config.RecordErrorStacks),
+		"status": "failed",
+	}
+}
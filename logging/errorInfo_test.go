@@ -0,0 +1,60 @@
+package logging
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+	"testing"
+)
+
+func TestErrorInfoChain(t *testing.T) {
+	err := fmt.Errorf("loading index: %w", errors.Join(fs.ErrNotExist, errors.New("cache cold")))
+	info := newErrorInfo(err, false)
+	if info.Type != "*fmt.wrapError" || info.Message != err.Error() || info.Stack != "" {
+		t.Errorf("unexpected error info %+v", info)
+	}
+	var messages []string
+	for _, wrapped := range info.Chain {
+		messages = append(messages, wrapped.Message)
+	}
+	want := []string{"file does not exist\ncache cold", "file does not exist", "cache cold"}
+	if strings.Join(messages, "|") != strings.Join(want, "|") {
+		t.Errorf("expected chain %q, got %q", want, messages)
+	}
+}
+
+func TestSetError(t *testing.T) {
+	l, ts := newTestLogger(t, &LoggerConfig{RecordErrorStacks: true})
+	trace := l.Trace(&TraceConfig{Id: "trace-1"})
+	trace.AddRetrieval(&RetrievalConfig{Id: "retrieval-1"}).SetError(fs.ErrPermission)
+	l.SetSpanError("span-1", errors.New("tool failed"))
+	trace.SetError(nil)
+	trace.AddGeneration(&GenerationConfig{Id: "generation-1"}).RecordError(fmt.Errorf("completion failed: %w", fs.ErrClosed))
+	l.Flush()
+
+	var commits []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(ts.logs()), "\n") {
+		if !strings.Contains(line, "action=update") {
+			continue
+		}
+		var data map[string]interface{}
+		if err := json.Unmarshal([]byte(line[strings.Index(line, "data=")+5:len(line)-1]), &data); err != nil {
+			t.Fatal(err)
+		}
+		commits = append(commits, data)
+	}
+	if len(commits) != 3 {
+		t.Fatalf("expected 3 error updates, got %d\n%s", len(commits), ts.logs())
+	}
+	for _, data := range commits {
+		info, _ := data["error"].(map[string]interface{})
+		if data["status"] != "failed" || info["type"] == "" || !strings.Contains(info["stack"].(string), "TestSetError") {
+			t.Errorf("unexpected error update %v", data)
+		}
+	}
+	if chain, _ := commits[2]["error"].(map[string]interface{})["chain"].([]interface{}); len(chain) != 1 {
+		t.Errorf("expected the wrapped error in the generation error chain, got %v", commits[2])
+	}
+}
@@ -0,0 +1,54 @@
+package logging
+
+import "errors"
+
+var (
+	// ErrPushFailed is wrapped by errors reported when logs cannot be pushed
+	// to the server.
+	ErrPushFailed = errors.New("maxim: failed to push logs")
+	// ErrSpoolFailed is wrapped by errors reported when logs that could not
+	// be pushed cannot be written to disk either.
+	ErrSpoolFailed = errors.New("maxim: failed to spool logs")
+	// ErrSerializationFailed is wrapped by errors reported when a commit log
+	// cannot be encoded.
+	ErrSerializationFailed = errors.New("maxim: failed to serialize log")
+	// ErrEntityEnded is wrapped by errors reported when an entity handle is
+	// used after it ended, under EndedPolicyError.
+	ErrEntityEnded = errors.New("maxim: entity already ended")
+	// ErrNoParent is returned by WithSpan and WithGeneration when no parent
+	// is given and the context carries none.
+	ErrNoParent = errors.New("maxim: no parent entity")
+	// ErrInvalidId is wrapped by errors reported when an entity is created
+	// with an invalid id. The entity is still created with it.
+	ErrInvalidId = errors.New("maxim: invalid entity id")
+	// ErrOTLPQueueFull is wrapped by the error OTLPSink.Export returns when
+	// the batch is dropped because the export queue is full.
+	ErrOTLPQueueFull = errors.New("maxim: OTLP export queue is full")
+)
+
+// EndedPolicy is what happens when an entity handle is updated, or ended
+// again, after it ended. The update is never committed.
+//
+// Entity methods do not return errors, so under EndedPolicyError the error
+// is reported to LoggerConfig.OnError in place of a return value: callers
+// that need to react to late updates do so from OnError, or check Ended
+// before updating.
+type EndedPolicy int
+
+const (
+	// EndedPolicyWarn logs a warning through the SDK logger.
+	EndedPolicyWarn EndedPolicy = iota
+	// EndedPolicyIgnore drops the update silently.
+	EndedPolicyIgnore
+	// EndedPolicyError reports an error wrapping ErrEntityEnded to
+	// LoggerConfig.OnError instead of returning it.
+	EndedPolicyError
+)
+
+// Reasons passed to LoggerConfig.OnDrop.
+const (
+	DropReasonQueueFull           = "queue_full"
+	DropReasonSpoolFailed         = "spool_failed"
+	DropReasonSerializationFailed = "serialization_failed"
+	DropReasonDiscarded           = "discarded"
+)
@@ -1,5 +1,7 @@
 package logging
 
+import "time"
+
 type eventEmitter struct {
 	*base
 }
@@ -14,3 +16,20 @@ func (e *eventEmitter) AddEvent(id, name string, tags *map[string]string) {
 	}
 	e.commit("add-event", event)
 }
+
+// addEventAt adds an event that happened at t rather than now, e.g. a log
+// record. A zero t falls back to now.
+func (e *eventEmitter) addEventAt(id, name string, tags *map[string]string, t time.Time) {
+	if t.IsZero() {
+		t = e.writer.now()
+	}
+	event := map[string]interface{}{
+		"id":        id,
+		"name":      name,
+		"timestamp": t.UTC(),
+	}
+	if tags != nil {
+		event["tags"] = tags
+	}
+	e.commit("add-event", event)
+}
@@ -1,5 +1,10 @@
 package logging
 
+import (
+	"fmt"
+	"time"
+)
+
 type GenerationError struct {
 	Message string  `json:"message"`
 	Code    *string `json:"code,omitempty"`
@@ -94,6 +99,9 @@ type GenerationConfig struct {
 	MaximPromptID   *string                `json:"maximPromptId,omitempty"`
 	Messages        []CompletionRequest    `json:"messages"`
 	ModelParameters map[string]interface{} `json:"modelParameters"`
+	StartTimestamp  *time.Time             `json:"startTimestamp,omitempty"`
+	EndTimestamp    *time.Time             `json:"endTimestamp,omitempty"`
+	Metadata        map[string]interface{} `json:"metadata,omitempty"`
 }
 
 type Generation struct {
@@ -107,12 +115,16 @@ type Generation struct {
 }
 
 func newGeneration(c *GenerationConfig, w *writer) *Generation {
+	w.assignId(EntityGeneration, &c.Id)
 	return &Generation{
 		base: newBase(EntityGeneration, c.Id, &baseConfig{
-			SpanId: c.SpanId,
-			Name:   c.Name,
-			Tags:   c.Tags,
-			Id:     c.Id,
+			SpanId:         c.SpanId,
+			Name:           c.Name,
+			Tags:           c.Tags,
+			Id:             c.Id,
+			StartTimestamp: c.StartTimestamp,
+			EndTimestamp:   c.EndTimestamp,
+			Metadata:       c.Metadata,
 		}, w),
 		model:           c.Model,
 		provider:        c.Provider,
@@ -167,6 +179,18 @@ func (g *Generation) SetError(err *GenerationError) {
 	})
 }
 
+// RecordError records the error like Trace.SetError, with its chain and,
+// when LoggerConfig.RecordErrorStacks is set, its stack, and sets it as the
+// generation error.
+func (g *Generation) RecordError(err error) {
+	if err == nil {
+		return
+	}
+	errorType := fmt.Sprintf("%T", err)
+	g.error = &GenerationError{Message: err.Error(), Type: &errorType}
+	g.recordError(err)
+}
+
 func (g *Generation) data() map[string]interface{} {
 	base := g.base.data()
 	base["provider"] = g.provider
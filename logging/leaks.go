@@ -0,0 +1,105 @@
+package logging
+
+import (
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// TagAutoEnded is set on entities ended by leak detection.
+const TagAutoEnded = "auto-ended"
+
+// LeakDetectionConfig enables the tracking of entities created through the
+// logger that are never ended.
+type LeakDetectionConfig struct {
+	// MaxLifetime is how long an entity may stay open before it is reported,
+	// checked on every flush. It is measured from when the entity was
+	// created through the logger, not from its StartTimestamp, so entities
+	// recorded after the fact are not reported right away. 0 only reports
+	// entities still open on Cleanup.
+	MaxLifetime time.Duration
+	// AutoEnd ends the reported entities, tagged with TagAutoEnded.
+	AutoEnd bool
+	// OnLeak is called with every reported entity. Defaults to a warning
+	// through the SDK logger. It must not block.
+	OnLeak func(e LeakedEntity)
+}
+
+// LeakedEntity describes an entity that was not ended in time.
+type LeakedEntity struct {
+	Entity         Entity
+	Id             string
+	Name           string
+	StartTimestamp time.Time
+	// Stack is where the entity was created, recorded in debug mode only.
+	Stack string
+}
+
+type liveEntity struct {
+	base *base
+	// created is when the entity was registered, by the logger's clock.
+	created time.Time
+	stack   string
+}
+
+// leakDetector holds the entities that have not ended yet.
+type leakDetector struct {
+	config LeakDetectionConfig
+	debug  bool
+	live   sync.Map
+}
+
+func newLeakDetector(c *LeakDetectionConfig, debugMode bool) *leakDetector {
+	return &leakDetector{config: *c, debug: debugMode}
+}
+
+func (d *leakDetector) track(b *base) {
+	e := &liveEntity{base: b, created: b.writer.now()}
+	if d.debug {
+		e.stack = string(debug.Stack())
+	}
+	d.live.Store(liveKey(b.entity, b.id), e)
+}
+
+func (d *leakDetector) untrack(entity Entity, id string) {
+	d.live.Delete(liveKey(entity, id))
+}
+
+func liveKey(entity Entity, id string) string {
+	return string(entity) + ":" + id
+}
+
+// check reports the entities open for longer than MaxLifetime, or all of
+// them when all is set.
+func (d *leakDetector) check(w *writer, now time.Time, all bool) {
+	if !all && d.config.MaxLifetime <= 0 {
+		return
+	}
+	d.live.Range(func(key, value interface{}) bool {
+		e := value.(*liveEntity)
+		b := e.base
+		if !all && now.Sub(e.created) < d.config.MaxLifetime {
+			return true
+		}
+		d.live.Delete(key)
+		leaked := LeakedEntity{
+			Entity:         b.entity,
+			Id:             b.id,
+			StartTimestamp: b.startTimestamp,
+			Stack:          e.stack,
+		}
+		if b.name != nil {
+			leaked.Name = *b.name
+		}
+		if d.config.OnLeak != nil {
+			d.config.OnLeak(leaked)
+		} else {
+			w.logger.Warn("entity was never ended", "entity", leaked.Entity, "entityId", leaked.Id, "name", leaked.Name, "stack", leaked.Stack)
+		}
+		if d.config.AutoEnd {
+			b.AddTag(TagAutoEnded, "true")
+			b.EndAt(now)
+		}
+		return true
+	})
+}
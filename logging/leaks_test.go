@@ -0,0 +1,100 @@
+package logging
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLeakDetectionOnCleanup(t *testing.T) {
+	var mutex sync.Mutex
+	var leaked []LeakedEntity
+	l, ts := newTestLogger(t, &LoggerConfig{
+		IsDebug: true,
+		LeakDetection: &LeakDetectionConfig{
+			AutoEnd: true,
+			OnLeak: func(e LeakedEntity) {
+				mutex.Lock()
+				leaked = append(leaked, e)
+				mutex.Unlock()
+			},
+		},
+	})
+	trace := l.Trace(&TraceConfig{Id: "trace-1", Name: strPtr("checkout")})
+	trace.AddSpan(&SpanConfig{Id: "span-1"}).End()
+	l.AddGenerationToTrace("trace-1", &GenerationConfig{Id: "generation-1"})
+	l.EndGeneration("generation-1")
+	l.Cleanup()
+
+	if len(leaked) != 1 {
+		t.Fatalf("expected only the trace to leak, got %+v", leaked)
+	}
+	if leaked[0].Id != "trace-1" || leaked[0].Name != "checkout" || leaked[0].Entity != EntityTrace {
+		t.Errorf("unexpected leak %+v", leaked[0])
+	}
+	if !strings.Contains(leaked[0].Stack, "TestLeakDetectionOnCleanup") {
+		t.Errorf("expected the creation stack in debug mode, got %q", leaked[0].Stack)
+	}
+	logs := ts.logs()
+	for _, want := range []string{`"auto-ended":"true"`, "trace{id=trace-1,action=end"} {
+		if !strings.Contains(logs, want) {
+			t.Errorf("pushed logs missing %q\n%s", want, logs)
+		}
+	}
+}
+
+func TestLeakDetectionMaxLifetime(t *testing.T) {
+	var leaked []LeakedEntity
+	var mutex sync.Mutex
+	now := utcNow()
+	l, _ := newTestLogger(t, &LoggerConfig{
+		Clock: ClockFunc(func() time.Time {
+			mutex.Lock()
+			defer mutex.Unlock()
+			return now
+		}),
+		LeakDetection: &LeakDetectionConfig{
+			MaxLifetime: time.Minute,
+			OnLeak:      func(e LeakedEntity) { leaked = append(leaked, e) },
+		},
+	})
+	l.Trace(&TraceConfig{Id: "stale"})
+	mutex.Lock()
+	now = now.Add(2 * time.Minute)
+	mutex.Unlock()
+	backfilled := now.Add(-time.Hour)
+	l.Trace(&TraceConfig{Id: "backfilled", StartTimestamp: &backfilled})
+	l.Trace(&TraceConfig{Id: "recent"})
+	l.Flush()
+	if len(leaked) != 1 || leaked[0].Id != "stale" || leaked[0].Stack != "" {
+		t.Errorf("expected only the stale trace to leak, without stack, got %+v", leaked)
+	}
+}
+
+func TestLeakDetectionAutoEndWhileInUse(t *testing.T) {
+	l, _ := newTestLogger(t, &LoggerConfig{
+		LeakDetection: &LeakDetectionConfig{
+			MaxLifetime: time.Nanosecond,
+			AutoEnd:     true,
+			OnLeak:      func(e LeakedEntity) {},
+		},
+		EndedPolicy: EndedPolicyIgnore,
+	})
+	trace := l.Trace(&TraceConfig{Id: "trace-1"})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			trace.AddTag("step", strings.Repeat("a", i))
+			trace.SetMetadata("step", i)
+			trace.Duration()
+		}
+	}()
+	time.Sleep(time.Millisecond)
+	l.Flush()
+	<-done
+	if !trace.Ended() {
+		t.Error("expected the trace to be auto-ended")
+	}
+}
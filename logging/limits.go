@@ -0,0 +1,258 @@
+package logging
+
+import (
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"unicode/utf8"
+)
+
+// TruncationMarker is appended to values cut by PayloadLimits.
+const TruncationMarker = "...[truncated]"
+
+// PayloadLimits bounds the size, in bytes, of user content sent with each
+// commit. Longer values are cut, suffixed with TruncationMarker, and the
+// entity gets a "maxim.truncated.<field>" tag holding the original length
+// (the longest one for messages and documents), sent as an update right
+// after the commit. A limit of 0 disables it.
+type PayloadLimits struct {
+	// Input limits trace, span and retrieval inputs. Every string of
+	// structured inputs is limited.
+	Input int
+	// Output limits trace and span outputs, like Input, and generation
+	// result texts.
+	Output int
+	// MessageContent limits the content of each generation message.
+	MessageContent int
+	// DocText limits each retrieved document.
+	DocText int
+	// TagValue limits each tag value.
+	TagValue int
+}
+
+// maxMeasureDepth bounds how deep hasLongString looks into a value.
+const maxMeasureDepth = 32
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+type payloadLimiter struct {
+	limits PayloadLimits
+}
+
+var _ Processor = (*payloadLimiter)(nil)
+
+func newPayloadLimiter(l *PayloadLimits) *payloadLimiter {
+	return &payloadLimiter{limits: *l}
+}
+
+// Process returns the commit with oversized values truncated. Like
+// redaction, it never modifies the original data: values are measured first,
+// and the data is only copied, and structured values only converted to their
+// JSON shape, when something has to be cut.
+func (p *payloadLimiter) Process(cl *CommitLog) (*CommitLog, bool) {
+	data, ok := cl.data.(map[string]interface{})
+	if !ok {
+		return cl, true
+	}
+	truncated := map[string]int{}
+	var limited map[string]interface{}
+	set := func(key string, value interface{}) {
+		if limited == nil {
+			limited = make(map[string]interface{}, len(data))
+			for k, v := range data {
+				limited[k] = v
+			}
+		}
+		limited[key] = value
+	}
+	for field, limit := range map[string]int{"input": p.limits.Input, "output": p.limits.Output} {
+		if value := data[field]; value != nil && limit > 0 && hasLongString(value, limit) {
+			if s, ok := value.(string); ok {
+				set(field, p.truncateField(truncated, field, s, limit))
+			} else {
+				set(field, p.limitStrings(truncated, field, toGeneric(value), limit))
+			}
+		}
+	}
+	if messages := data["messages"]; messages != nil && p.limits.MessageContent > 0 && hasLongString(messages, p.limits.MessageContent) {
+		set("messages", p.limitTexts(truncated, "messages", toGeneric(messages), p.limits.MessageContent))
+	}
+	if result := data["result"]; result != nil && p.limits.Output > 0 && hasLongString(result, p.limits.Output) {
+		set("result", p.limitTexts(truncated, "output", toGeneric(result), p.limits.Output))
+	}
+	if docs := data["docs"]; docs != nil && p.limits.DocText > 0 && hasLongString(docs, p.limits.DocText) {
+		limitedDocs, _ := toGeneric(docs).([]interface{})
+		for i, doc := range limitedDocs {
+			if s, ok := doc.(string); ok {
+				limitedDocs[i] = p.truncateField(truncated, "docs", s, p.limits.DocText)
+			}
+		}
+		set("docs", limitedDocs)
+	}
+	tags := map[string]string{}
+	switch t := data["tags"].(type) {
+	case map[string]string:
+		tags = t
+	case *map[string]string:
+		if t != nil {
+			tags = *t
+		}
+	}
+	if len(truncated) == 0 && !p.tagsOverLimit(tags) {
+		// Values can measure over a limit without being cut, e.g. a long
+		// message role; the commit is still sent as is.
+		return cl, true
+	}
+	if p.tagsOverLimit(tags) {
+		limitedTags := make(map[string]string, len(tags))
+		for key, value := range tags {
+			limitedTags[key] = truncate(value, p.limits.TagValue)
+		}
+		set("tags", limitedTags)
+	}
+	limitedLog := cl
+	if limited != nil {
+		limitedLog = cl.WithData(limited)
+	}
+	if len(truncated) > 0 {
+		// Results and ends carry no tags, so the original lengths follow
+		// as an update of the entity.
+		truncationTags := make(map[string]string, len(truncated))
+		for field, length := range truncated {
+			truncationTags["maxim.truncated."+field] = strconv.Itoa(length)
+		}
+		limitedLog.then = append(limitedLog.then, NewCommitLog(cl.entity, cl.entityID, "update", map[string]interface{}{
+			"tags": truncationTags,
+		}))
+	}
+	return limitedLog, true
+}
+
+// hasLongString reports whether v holds a string longer than limit anywhere,
+// without encoding it. It errs on the side of true for values whose JSON
+// encoding it cannot see, which are then converted and checked for real.
+func hasLongString(v interface{}, limit int) bool {
+	return longString(reflect.ValueOf(v), limit, 0)
+}
+
+func longString(v reflect.Value, limit, depth int) bool {
+	if !v.IsValid() {
+		return false
+	}
+	if depth > maxMeasureDepth {
+		return true
+	}
+	if v.Type().Implements(jsonMarshalerType) || v.Type().Implements(textMarshalerType) {
+		return true
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.Len() > limit
+	case reflect.Interface, reflect.Pointer:
+		return !v.IsNil() && longString(v.Elem(), limit, depth+1)
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			// Encoded as base64.
+			return base64.StdEncoding.EncodedLen(v.Len()) > limit
+		}
+		for i := 0; i < v.Len(); i++ {
+			if longString(v.Index(i), limit, depth+1) {
+				return true
+			}
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			if longString(iter.Value(), limit, depth+1) {
+				return true
+			}
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if field := v.Type().Field(i); (field.IsExported() || field.Anonymous) && longString(v.Field(i), limit, depth+1) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (p *payloadLimiter) tagsOverLimit(tags map[string]string) bool {
+	if p.limits.TagValue <= 0 {
+		return false
+	}
+	for _, value := range tags {
+		if len(value) > p.limits.TagValue {
+			return true
+		}
+	}
+	return false
+}
+
+// truncateField truncates s and records its original length under field,
+// keeping the longest one seen.
+func (p *payloadLimiter) truncateField(truncated map[string]int, field, s string, limit int) string {
+	if limit <= 0 || len(s) <= limit {
+		return s
+	}
+	if len(s) > truncated[field] {
+		truncated[field] = len(s)
+	}
+	return truncate(s, limit)
+}
+
+// limitTexts truncates message contents and completion texts found in the
+// JSON shape of messages or results.
+func (p *payloadLimiter) limitTexts(truncated map[string]int, field string, v interface{}, limit int) interface{} {
+	switch value := v.(type) {
+	case []interface{}:
+		for i := range value {
+			value[i] = p.limitTexts(truncated, field, value[i], limit)
+		}
+	case map[string]interface{}:
+		for k, item := range value {
+			if s, ok := item.(string); ok && (k == "content" || k == "text") {
+				value[k] = p.truncateField(truncated, field, s, limit)
+				continue
+			}
+			value[k] = p.limitTexts(truncated, field, item, limit)
+		}
+	}
+	return v
+}
+
+// limitStrings truncates every string found in the JSON shape of a
+// structured input or output.
+func (p *payloadLimiter) limitStrings(truncated map[string]int, field string, v interface{}, limit int) interface{} {
+	switch value := v.(type) {
+	case string:
+		return p.truncateField(truncated, field, value, limit)
+	case []interface{}:
+		for i := range value {
+			value[i] = p.limitStrings(truncated, field, value[i], limit)
+		}
+	case map[string]interface{}:
+		for k := range value {
+			value[k] = p.limitStrings(truncated, field, value[k], limit)
+		}
+	}
+	return v
+}
+
+// truncate cuts s to at most limit bytes, on a rune boundary, and appends
+// TruncationMarker.
+func truncate(s string, limit int) string {
+	if limit <= 0 || len(s) <= limit {
+		return s
+	}
+	cut := limit
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + TruncationMarker
+}
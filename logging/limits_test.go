@@ -0,0 +1,63 @@
+package logging
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPayloadLimitsTruncateContent(t *testing.T) {
+	l, ts := newTestLogger(t, &LoggerConfig{
+		Limits: &PayloadLimits{Input: 10, Output: 8, MessageContent: 5, DocText: 4, TagValue: 6},
+	})
+	trace := l.Trace(&TraceConfig{Id: "trace-1", Tags: &map[string]string{"team": "search-relevance"}})
+	trace.SetInput(strings.Repeat("a", 100))
+	trace.SetOutput("short")
+	generation := trace.AddGeneration(&GenerationConfig{Id: "generation-1"})
+	generation.AddMessages([]CompletionRequest{{Role: "user", Content: "hello world"}})
+	generation.SetResult(map[string]interface{}{"choices": []interface{}{map[string]interface{}{"text": "a long completion"}}})
+	retrieval := trace.AddRetrieval(&RetrievalConfig{Id: "retrieval-1"})
+	retrieval.SetOutput([]string{"document one", "doc"})
+	l.Flush()
+
+	logs := ts.logs()
+	for _, want := range []string{
+		`"team":"search...[truncated]"`,
+		`trace{id=trace-1,action=update,data={"input":"aaaaaaaaaa...[truncated]"}}`,
+		`trace{id=trace-1,action=update,data={"tags":{"maxim.truncated.input":"100"}}}`,
+		`"output":"short"}`,
+		`"content":"hello...[truncated]"`,
+		`generation{id=generation-1,action=update,data={"tags":{"maxim.truncated.messages":"11"}}}`,
+		`"text":"a long c...[truncated]"`,
+		`generation{id=generation-1,action=update,data={"tags":{"maxim.truncated.output":"17"}}}`,
+		`"docs":["docu...[truncated]","doc"]`,
+		`retrieval{id=retrieval-1,action=update,data={"tags":{"maxim.truncated.docs":"12"}}}`,
+	} {
+		if !strings.Contains(logs, want) {
+			t.Errorf("pushed logs missing %q\n%s", want, logs)
+		}
+	}
+}
+
+func TestTruncateKeepsRuneBoundaries(t *testing.T) {
+	if got := truncate("héllo", 2); got != "h"+TruncationMarker {
+		t.Errorf("unexpected truncation %q", got)
+	}
+}
+
+func TestPayloadLimitsPassShortCommitsThrough(t *testing.T) {
+	p := newPayloadLimiter(&PayloadLimits{Input: 10, MessageContent: 5, TagValue: 6})
+	cl := NewCommitLog(EntityGeneration, "generation-1", "update", map[string]interface{}{
+		"input":    map[string]interface{}{"query": "short"},
+		"messages": []CompletionRequest{{Role: "user", Content: "hi"}},
+		"tags":     map[string]string{"team": "ml"},
+	})
+	if got, _ := p.Process(cl); got != cl {
+		t.Errorf("commit within limits was copied: %+v", got)
+	}
+	cl = NewCommitLog(EntityGeneration, "generation-1", "update", map[string]interface{}{
+		"messages": []CompletionRequest{{Role: "user", Content: "hello world"}},
+	})
+	if got, _ := p.Process(cl); got == cl || !strings.Contains(got.Serialize(), `"content":"hello...[truncated]"`) {
+		t.Errorf("long message was not truncated: %s", got.Serialize())
+	}
+}
@@ -1,10 +1,72 @@
 package logging
 
+import (
+	"log/slog"
+	"net/http"
+)
+
 type LoggerConfig struct {
 	Id                   string
 	AutoFlush            *bool
 	FlushIntervalSeconds *int
 	IsDebug              bool
+	// DefaultTags are added to every entity created through the logger.
+	// Tags set on an entity override them.
+	DefaultTags map[string]string
+	// DetectResource adds ResourceTags to the default tags. DefaultTags
+	// override them.
+	DetectResource bool
+	// Sinks receive every flushed batch of commit logs alongside the push to
+	// Maxim, e.g. an OTLPSink mirroring the logs into an OpenTelemetry backend.
+	Sinks []Sink
+	// Sampler decides which traces are recorded. Defaults to recording all.
+	Sampler Sampler
+	// TailSampling, when set, holds the commits of each trace until it ends
+	// and keeps only traces matching its rules.
+	TailSampling *TailSamplingConfig
+	// Redaction masks PII in inputs, outputs, messages, results and
+	// retrieved documents before commits are enqueued.
+	Redaction *RedactionConfig
+	// Limits truncates oversized inputs, outputs, messages, documents and
+	// tag values, after redaction.
+	Limits *PayloadLimits
+	// Processors run in order on every commit, after redaction and limits, and may
+	// enrich, rewrite or veto it.
+	Processors []Processor
+	// EndedPolicy is what happens when an entity handle is used after it
+	// ended. Defaults to EndedPolicyWarn.
+	EndedPolicy EndedPolicy
+	// LeakDetection, when set, reports entities that are never ended, and
+	// may end them.
+	LeakDetection *LeakDetectionConfig
+	// CascadeEnd ends the open children of an entity handle, with the same
+	// end timestamp, when it ends. Only children added through the handle,
+	// e.g. with Trace.AddSpan or Session.AddTrace, are known to it.
+	CascadeEnd bool
+	// RecordErrorStacks adds the stack trace of the caller to the errors
+	// recorded with SetError.
+	RecordErrorStacks bool
+	// Clock provides the timestamps of entities created without explicit
+	// ones. Defaults to the system clock.
+	Clock Clock
+	// PausePushes starts the logger with pushes paused: flushed logs are
+	// spooled to disk until ResumePushes is called.
+	PausePushes bool
+	// HTTPClient is used to push logs. Defaults to a new http.Client.
+	HTTPClient *http.Client
+	// Logger receives the SDK's own diagnostics. Defaults to stdout at debug
+	// level when IsDebug is set, and to warnings and errors on stderr otherwise.
+	Logger *slog.Logger
+	// MaxQueueSize bounds the number of commits waiting for the next flush.
+	// Commits made while the queue is full are dropped. 0 means unbounded.
+	MaxQueueSize int
+	// OnError is called with every failure of the SDK itself: push, spool
+	// and serialization failures. Errors wrap ErrPushFailed, ErrSpoolFailed
+	// or ErrSerializationFailed. It must not block.
+	OnError func(error)
+	// OnDrop is called whenever logs are lost for good, with the number of
+	// logs and one of the DropReason constants. It must not block.
+	OnDrop func(n int, reason string)
 }
 
 type Logger struct {
@@ -21,15 +83,43 @@ func NewLogger(baseUrl string, apiKey string, c *LoggerConfig) *Logger {
 	if c.FlushIntervalSeconds != nil {
 		flushIntervalSeconds = *c.FlushIntervalSeconds
 	}
+	var defaultTags map[string]string
+	if c.DetectResource || len(c.DefaultTags) > 0 {
+		defaultTags = map[string]string{}
+		if c.DetectResource {
+			defaultTags = ResourceTags()
+		}
+		for key, value := range c.DefaultTags {
+			defaultTags[key] = value
+		}
+	}
 	return &Logger{
 		config: *c,
 		writer: newWriter(&writerConfig{
 			BaseUrl:              baseUrl,
 			ApiKey:               apiKey,
+			HTTPClient:           c.HTTPClient,
 			RepoId:               c.Id,
 			AutoFlush:            autoFlush,
 			FlushIntervalSeconds: flushIntervalSeconds,
 			IsDebug:              c.IsDebug,
+			Sinks:                c.Sinks,
+			Sampler:              c.Sampler,
+			TailSampling:         c.TailSampling,
+			Redaction:            c.Redaction,
+			Limits:               c.Limits,
+			DefaultTags:          defaultTags,
+			PausePushes:          c.PausePushes,
+			EndedPolicy:          c.EndedPolicy,
+			LeakDetection:        c.LeakDetection,
+			CascadeEnd:           c.CascadeEnd,
+			RecordErrorStacks:    c.RecordErrorStacks,
+			Clock:                c.Clock,
+			Processors:           c.Processors,
+			Logger:               c.Logger,
+			MaxQueueSize:         c.MaxQueueSize,
+			OnError:              c.OnError,
+			OnDrop:               c.OnDrop,
 		}),
 	}
 }
@@ -48,6 +138,11 @@ func (l *Logger) AddTagToSession(sessionId, key, value string) {
 	addTag(l.writer, EntitySession, sessionId, key, value)
 }
 
+// SetSessionMetadata sets a typed metadata value on the session.
+func (l *Logger) SetSessionMetadata(sessionId, key string, value interface{}) {
+	setMetadata(l.writer, EntitySession, sessionId, key, value)
+}
+
 func (l *Logger) SessionEnd(sessionId string) {
 	end(l.writer, EntitySession, sessionId)
 }
@@ -65,37 +160,54 @@ func (l *Logger) Trace(c *TraceConfig) *Trace {
 
 func (l *Logger) AddGenerationToTrace(traceId string, c *GenerationConfig) *Generation {
 	g := newGeneration(c, l.writer)
+	if g.skipIfUnsampled(l.writer.unsampledTrace(traceId)) {
+		return g
+	}
 	gData := g.data()
 	gData["id"] = c.Id
-	l.writer.commit(newCommitLog(EntityTrace, traceId, "add-generation", gData))
+	l.writer.commit(NewCommitLog(EntityTrace, traceId, "add-generation", gData))
 	return g
 }
 
 func (l *Logger) AddRetrievalToTrace(traceId string, c *RetrievalConfig) *Retrieval {
 	r := newRetrieval(c, l.writer)
+	if r.skipIfUnsampled(l.writer.unsampledTrace(traceId)) {
+		return r
+	}
 	rData := r.data()
 	rData["id"] = c.Id
-	l.writer.commit(newCommitLog(EntityTrace, traceId, "add-retrieval", rData))
+	l.writer.commit(NewCommitLog(EntityTrace, traceId, "add-retrieval", rData))
 	return r
 }
 
-func (l *Logger) SetTraceInput(traceId, input string) {
-	l.writer.commit(newCommitLog(EntityTrace, traceId, "update", map[string]interface{}{
+// SetTraceInput sets the input of the trace: a string, or any JSON
+// serializable value such as a struct or a json.RawMessage.
+func (l *Logger) SetTraceInput(traceId string, input interface{}) {
+	l.writer.commit(NewCommitLog(EntityTrace, traceId, "update", map[string]interface{}{
 		"input": input,
 	}))
 }
 
-func (l *Logger) SetTraceOutput(traceId, output string) {
-	l.writer.commit(newCommitLog(EntityTrace, traceId, "update", map[string]interface{}{
+// SetTraceOutput sets the output of the trace, like SetTraceInput.
+func (l *Logger) SetTraceOutput(traceId string, output interface{}) {
+	l.writer.commit(NewCommitLog(EntityTrace, traceId, "update", map[string]interface{}{
 		"output": output,
 	}))
 }
 
+// SetTraceError records the error on the trace and marks it as failed.
+func (l *Logger) SetTraceError(traceId string, err error) {
+	setError(l.writer, EntityTrace, traceId, err)
+}
+
 func (l *Logger) AddSpanToTrace(traceId string, c *SpanConfig) *Span {
 	s := newSpan(c, l.writer)
+	if s.skipIfUnsampled(l.writer.unsampledTrace(traceId)) {
+		return s
+	}
 	sData := s.data()
 	sData["id"] = c.Id
-	l.writer.commit(newCommitLog(EntityTrace, traceId, "add-span", sData))
+	l.writer.commit(NewCommitLog(EntityTrace, traceId, "add-span", sData))
 	return s
 }
 
@@ -111,6 +223,11 @@ func (l *Logger) AddEventToTrace(traceId, eventId, event string, tags *map[strin
 	addEvent(l.writer, EntityTrace, traceId, eventId, event, tags)
 }
 
+// SetTraceMetadata sets a typed metadata value on the trace.
+func (l *Logger) SetTraceMetadata(traceId, key string, value interface{}) {
+	setMetadata(l.writer, EntityTrace, traceId, key, value)
+}
+
 func (l *Logger) EndTrace(traceId string) {
 	end(l.writer, EntityTrace, traceId)
 }
@@ -118,19 +235,19 @@ func (l *Logger) EndTrace(traceId string) {
 // Generation methods
 
 func (l *Logger) SetModelToGeneration(gId, model string) {
-	l.writer.commit(newCommitLog(EntityGeneration, gId, "update", map[string]interface{}{
+	l.writer.commit(NewCommitLog(EntityGeneration, gId, "update", map[string]interface{}{
 		"model": model,
 	}))
 }
 
 func (l *Logger) AddMessageToGeneration(gId string, message CompletionRequest) {
-	l.writer.commit(newCommitLog(EntityGeneration, gId, "update", map[string]interface{}{
+	l.writer.commit(NewCommitLog(EntityGeneration, gId, "update", map[string]interface{}{
 		"messages": []CompletionRequest{message},
 	}))
 }
 
 func (l *Logger) SetModelParametersForGeneration(gId string, params map[string]interface{}) {
-	l.writer.commit(newCommitLog(EntityGeneration, gId, "update", map[string]interface{}{
+	l.writer.commit(NewCommitLog(EntityGeneration, gId, "update", map[string]interface{}{
 		"modelParameters": params,
 	}))
 }
@@ -144,18 +261,23 @@ func (l *Logger) AddEventToGeneration(gId, eventId, event string, tags *map[stri
 }
 
 func (l *Logger) AddResultToGeneration(gId string, result interface{}) {
-	l.writer.commit(newCommitLog(EntityGeneration, gId, "result", map[string]interface{}{
+	l.writer.commit(NewCommitLog(EntityGeneration, gId, "result", map[string]interface{}{
 		"result": result,
 	}))
 	end(l.writer, EntityGeneration, gId)
 }
 
 func (l *Logger) SetGenerationError(gId string, error *GenerationError) {
-	l.writer.commit(newCommitLog(EntityGeneration, gId, "error", map[string]interface{}{
+	l.writer.commit(NewCommitLog(EntityGeneration, gId, "error", map[string]interface{}{
 		"error": error,
 	}))
 }
 
+// SetGenerationMetadata sets a typed metadata value on the generation.
+func (l *Logger) SetGenerationMetadata(gId, key string, value interface{}) {
+	setMetadata(l.writer, EntityGeneration, gId, key, value)
+}
+
 func (l *Logger) EndGeneration(gId string) {
 	end(l.writer, EntityGeneration, gId)
 }
@@ -164,25 +286,34 @@ func (l *Logger) EndGeneration(gId string) {
 
 func (l *Logger) AddGenerationToSpan(sId string, c *GenerationConfig) *Generation {
 	g := newGeneration(c, l.writer)
+	if g.skipIfUnsampled(l.writer.unsampledTrace(sId)) {
+		return g
+	}
 	gData := g.data()
 	gData["id"] = c.Id
-	l.writer.commit(newCommitLog(EntitySpan, sId, "add-generation", gData))
+	l.writer.commit(NewCommitLog(EntitySpan, sId, "add-generation", gData))
 	return g
 }
 
 func (l *Logger) AddRetrievalToSpan(sId string, c *RetrievalConfig) *Retrieval {
 	r := newRetrieval(c, l.writer)
+	if r.skipIfUnsampled(l.writer.unsampledTrace(sId)) {
+		return r
+	}
 	rData := r.data()
 	rData["id"] = c.Id
-	l.writer.commit(newCommitLog(EntitySpan, sId, "add-retrieval", rData))
+	l.writer.commit(NewCommitLog(EntitySpan, sId, "add-retrieval", rData))
 	return r
 }
 
 func (l *Logger) AddSubSpanToSpan(sId string, c *SpanConfig) *Span {
 	s := newSpan(c, l.writer)
+	if s.skipIfUnsampled(l.writer.unsampledTrace(sId)) {
+		return s
+	}
 	sData := s.data()
 	sData["id"] = c.Id
-	l.writer.commit(newCommitLog(EntitySpan, sId, "add-span", sData))
+	l.writer.commit(NewCommitLog(EntitySpan, sId, "add-span", sData))
 	return s
 }
 
@@ -194,33 +325,91 @@ func (l *Logger) AddEventToSpan(spanId, eventId, event string, tags *map[string]
 	addEvent(l.writer, EntitySpan, spanId, eventId, event, tags)
 }
 
+// SetSpanInput sets the input of the span, like SetTraceInput.
+func (l *Logger) SetSpanInput(spanId string, input interface{}) {
+	l.writer.commit(NewCommitLog(EntitySpan, spanId, "update", map[string]interface{}{
+		"input": input,
+	}))
+}
+
+// SetSpanOutput sets the output of the span, like SetTraceInput.
+func (l *Logger) SetSpanOutput(spanId string, output interface{}) {
+	l.writer.commit(NewCommitLog(EntitySpan, spanId, "update", map[string]interface{}{
+		"output": output,
+	}))
+}
+
+// SetSpanError records the error on the span and marks it as failed.
+func (l *Logger) SetSpanError(spanId string, err error) {
+	setError(l.writer, EntitySpan, spanId, err)
+}
+
+// SetSpanMetadata sets a typed metadata value on the span.
+func (l *Logger) SetSpanMetadata(spanId, key string, value interface{}) {
+	setMetadata(l.writer, EntitySpan, spanId, key, value)
+}
+
 func (l *Logger) EndSpan(spanId string) {
 	end(l.writer, EntitySpan, spanId)
 }
 
 // Retrieval methods
 
+// SetRetrievalMetadata sets a typed metadata value on the retrieval.
+func (l *Logger) SetRetrievalMetadata(rId, key string, value interface{}) {
+	setMetadata(l.writer, EntityRetrieval, rId, key, value)
+}
+
 func (l *Logger) EndRetrieval(rId string) {
 	end(l.writer, EntityRetrieval, rId)
 }
 
 func (l *Logger) SetRetrievalInput(rId, input string) {
-	l.writer.commit(newCommitLog(EntityRetrieval, rId, "update", map[string]interface{}{
+	l.writer.commit(NewCommitLog(EntityRetrieval, rId, "update", map[string]interface{}{
 		"input": input,
 	}))
 }
 
 func (l *Logger) SetRetrievalOutput(rId string, output []string) {
-	l.writer.commit(newCommitLog(EntityRetrieval, rId, "end", map[string]interface{}{
+	l.writer.commit(NewCommitLog(EntityRetrieval, rId, "end", map[string]interface{}{
 		"docs":         output,
-		"endTimestamp": utcNow(),
+		"endTimestamp": l.writer.now(),
 	}))
 }
 
+// SetRetrievalError records the error on the retrieval and marks it as
+// failed.
+func (l *Logger) SetRetrievalError(rId string, err error) {
+	setError(l.writer, EntityRetrieval, rId, err)
+}
+
 func (l *Logger) AddTagToRetrieval(rId, key, value string) {
 	addTag(l.writer, EntityRetrieval, rId, key, value)
 }
 
+// Flush pushes all pending logs to the server without stopping the logger.
+func (l *Logger) Flush() {
+	l.writer.flush()
+}
+
+// PausePushes makes flushes spool logs to disk instead of pushing them, e.g.
+// while the Maxim API cannot be reached.
+func (l *Logger) PausePushes() {
+	l.writer.paused.Store(true)
+}
+
+// DiscardPushes makes flushes drop logs, reported to OnDrop with
+// DropReasonDiscarded, instead of pushing or spooling them, e.g. once the
+// log repository was rejected. It cannot be undone.
+func (l *Logger) DiscardPushes() {
+	l.writer.discarding.Store(true)
+}
+
+// ResumePushes re-enables pushes and pushes the logs spooled meanwhile.
+func (l *Logger) ResumePushes() {
+	l.writer.resume()
+}
+
 func (l *Logger) Cleanup() {
 	l.writer.cleanup()
 }
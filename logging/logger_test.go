@@ -0,0 +1,259 @@
+package logging
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// testServer is a fake Maxim API recording every pushed batch of logs.
+type testServer struct {
+	*httptest.Server
+	mutex  sync.Mutex
+	pushes []string
+}
+
+func newTestServer(t *testing.T) *testServer {
+	ts := &testServer{}
+	ts.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		ts.mutex.Lock()
+		ts.pushes = append(ts.pushes, string(body))
+		ts.mutex.Unlock()
+		w.Write([]byte("{}"))
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func (ts *testServer) logs() string {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+	return strings.Join(ts.pushes, "")
+}
+
+func newTestLogger(t *testing.T, c *LoggerConfig) (*Logger, *testServer) {
+	ts := newTestServer(t)
+	if c == nil {
+		c = &LoggerConfig{}
+	}
+	if c.Id == "" {
+		c.Id = "test-repo"
+	}
+	l := NewLogger(ts.URL, "test-key", c)
+	t.Cleanup(l.Cleanup)
+	return l, ts
+}
+
+func TestLoggerFlushPushesCommits(t *testing.T) {
+	l, ts := newTestLogger(t, nil)
+	trace := l.Trace(&TraceConfig{Id: "trace-1"})
+	trace.SetInput("hello")
+	trace.End()
+	l.Flush()
+	logs := ts.logs()
+	for _, want := range []string{
+		"trace{id=trace-1,action=create",
+		`trace{id=trace-1,action=update,data={"input":"hello"}}`,
+		"trace{id=trace-1,action=end",
+	} {
+		if !strings.Contains(logs, want) {
+			t.Errorf("pushed logs missing %q\n%s", want, logs)
+		}
+	}
+}
+
+func TestLoggerReportsPushFailuresToInjectedLogger(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("not json"))
+	}))
+	defer server.Close()
+	var out strings.Builder
+	var mutex sync.Mutex
+	sdkLogger := slog.New(slog.NewJSONHandler(&lockedWriter{w: &out, mutex: &mutex}, nil))
+	l := NewLogger(server.URL, "test-key", &LoggerConfig{Id: "test-repo", Logger: sdkLogger})
+	l.writer.logsDir = t.TempDir()
+	defer l.Cleanup()
+
+	l.Trace(&TraceConfig{Id: "trace-1"}).End()
+	l.Flush()
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	for _, want := range []string{
+		`"level":"WARN","msg":"failed to push logs","repoId":"test-repo","batchSize":2,"attempt":3`,
+		`"level":"ERROR","msg":"failed to flush logs, spooled to disk"`,
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("SDK log output missing %q\n%s", want, out.String())
+		}
+	}
+}
+
+type lockedWriter struct {
+	w     io.Writer
+	mutex *sync.Mutex
+}
+
+func (lw *lockedWriter) Write(p []byte) (int, error) {
+	lw.mutex.Lock()
+	defer lw.mutex.Unlock()
+	return lw.w.Write(p)
+}
+
+func TestLoggerReportsErrorsAndDrops(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("not json"))
+	}))
+	defer server.Close()
+	var mutex sync.Mutex
+	var errs []error
+	drops := map[string]int{}
+	l := NewLogger(server.URL, "test-key", &LoggerConfig{
+		Id:           "test-repo",
+		MaxQueueSize: 2,
+		Logger:       slog.New(slog.NewTextHandler(io.Discard, nil)),
+		OnError: func(err error) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			errs = append(errs, err)
+		},
+		OnDrop: func(n int, reason string) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			drops[reason] += n
+		},
+	})
+	// A regular file where the spool directory should be makes spooling fail.
+	spoolPath := t.TempDir() + "/spool"
+	os.WriteFile(spoolPath, nil, 0644)
+	l.writer.logsDir = spoolPath
+	defer l.Cleanup()
+
+	trace := l.Trace(&TraceConfig{Id: "trace-1"})
+	trace.SetInput("input")
+	trace.End() // dropped, the queue is full
+	l.Flush()
+	l.AddResultToGeneration("generation-1", math.Inf(1))
+	l.Flush()
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if drops[DropReasonQueueFull] != 1 {
+		t.Errorf("expected 1 queue_full drop, got %v", drops)
+	}
+	if drops[DropReasonSpoolFailed] != 3 {
+		t.Errorf("expected 3 spool_failed drops, got %v", drops)
+	}
+	if drops[DropReasonSerializationFailed] != 1 {
+		t.Errorf("expected 1 serialization_failed drop, got %v", drops)
+	}
+	for _, target := range []error{ErrPushFailed, ErrSpoolFailed, ErrSerializationFailed} {
+		found := false
+		for _, err := range errs {
+			found = found || errors.Is(err, target)
+		}
+		if !found {
+			t.Errorf("no reported error wraps %v: %v", target, errs)
+		}
+	}
+}
+
+func TestSpooledPushFailuresAreReported(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	var mutex sync.Mutex
+	var errs []error
+	l := NewLogger(server.URL, "test-key", &LoggerConfig{
+		Id:     "test-repo",
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+		OnError: func(err error) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			errs = append(errs, err)
+		},
+	})
+	l.writer.logsDir = t.TempDir()
+	defer l.Cleanup()
+	l.writer.writeToFile("trace{id=trace-0,action=create,data={}}\n")
+
+	l.Trace(&TraceConfig{Id: "trace-1"})
+	l.Flush()
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	spooled := 0
+	for _, err := range errs {
+		if errors.Is(err, ErrPushFailed) && strings.Contains(err.Error(), "spooled batch") {
+			spooled++
+		}
+	}
+	if spooled != 1 {
+		t.Errorf("expected the failed push of the spooled batch to be reported, got %v", errs)
+	}
+	if n := l.Stats().PushFailures; n != 2 {
+		t.Errorf("expected 2 push failures, the spooled batch and the new one, got %d", n)
+	}
+}
+
+func TestEntityIdsAssignedAndValidated(t *testing.T) {
+	var errs []error
+	l, ts := newTestLogger(t, &LoggerConfig{OnError: func(err error) { errs = append(errs, err) }})
+	config := &TraceConfig{}
+	trace := l.Trace(config)
+	if trace.Id() == "" || config.Id != trace.Id() {
+		t.Fatalf("expected a generated id on the trace and its config, got %q", trace.Id())
+	}
+	generation := l.AddGenerationToTrace(trace.Id(), &GenerationConfig{})
+	trace.AddSpan(&SpanConfig{Id: "bad id"})
+	l.Flush()
+
+	if !strings.Contains(ts.logs(), `"id":"`+generation.Id()+`"`) {
+		t.Errorf("expected the generated generation id to be committed\n%s", ts.logs())
+	}
+	if len(errs) != 1 || !errors.Is(errs[0], ErrInvalidId) {
+		t.Errorf("expected a single ErrInvalidId, got %v", errs)
+	}
+}
+
+func TestStructuredInputsAndOutputs(t *testing.T) {
+	l, ts := newTestLogger(t, &LoggerConfig{
+		Redaction: &RedactionConfig{Detectors: []Detector{EmailDetector()}},
+		Limits:    &PayloadLimits{Input: 8},
+	})
+	type query struct {
+		User  string `json:"user"`
+		TopK  int    `json:"topK"`
+		Notes string `json:"notes"`
+	}
+	trace := l.Trace(&TraceConfig{Id: "trace-1"})
+	trace.SetInput(query{User: "a@b.io", TopK: 5, Notes: "short"})
+	trace.SetOutput(json.RawMessage(`{"answer":42}`))
+	span := trace.AddSpan(&SpanConfig{Id: "span-1"})
+	span.SetInput("plain")
+	l.SetSpanOutput("span-1", []interface{}{true, 1.5})
+	l.Flush()
+
+	logs := ts.logs()
+	for _, want := range []string{
+		`trace{id=trace-1,action=update,data={"input":{"notes":"short","topK":5,"user":"[REDACTE...[truncated]"}}}`,
+		`trace{id=trace-1,action=update,data={"tags":{"maxim.truncated.input":"16"}}}`,
+		`trace{id=trace-1,action=update,data={"output":{"answer":42}}}`,
+		`span{id=span-1,action=update,data={"input":"plain"}}`,
+		`span{id=span-1,action=update,data={"output":[true,1.5]}}`,
+	} {
+		if !strings.Contains(logs, want) {
+			t.Errorf("pushed logs missing %q\n%s", want, logs)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// checkMetadata reports metadata values that cannot be encoded as JSON,
+// which would otherwise fail the serialization of the whole commit.
+func checkMetadata(entity Entity, id, key string, value interface{}) error {
+	if _, err := json.Marshal(value); err != nil {
+		return fmt.Errorf("%w: metadata %q of %s %s: %v", ErrSerializationFailed, key, entity, id, err)
+	}
+	return nil
+}
+
+// checkedMetadata copies the metadata of an entity config, leaving out the
+// values that cannot be encoded as JSON, which are reported to
+// LoggerConfig.OnError like those given to SetMetadata.
+func checkedMetadata(w *writer, entity Entity, id string, m map[string]interface{}) map[string]interface{} {
+	c := copyMetadata(m)
+	for key, value := range c {
+		if err := checkMetadata(entity, id, key, value); err != nil {
+			w.reportError(err)
+			delete(c, key)
+		}
+	}
+	if len(c) == 0 {
+		return nil
+	}
+	return c
+}
+
+func copyMetadata(m map[string]interface{}) map[string]interface{} {
+	if len(m) == 0 {
+		return nil
+	}
+	c := make(map[string]interface{}, len(m))
+	for key, value := range m {
+		c[key] = value
+	}
+	return c
+}
@@ -0,0 +1,36 @@
+package logging
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestMetadata(t *testing.T) {
+	var errs []error
+	l, ts := newTestLogger(t, &LoggerConfig{OnError: func(err error) { errs = append(errs, err) }})
+	l.Session(&SessionConfig{Id: "session-1", Metadata: map[string]interface{}{"plan": "pro"}})
+	trace := l.Trace(&TraceConfig{Id: "trace-1", Metadata: map[string]interface{}{"seats": 3, "updates": make(chan int)}})
+	trace.SetMetadata("beta", true)
+	trace.SetMetadata("callback", func() {})
+	retrieval := trace.AddRetrieval(&RetrievalConfig{Id: "retrieval-1"})
+	retrieval.SetMetadata("config", map[string]interface{}{"topK": 5, "rerank": false})
+	l.SetGenerationMetadata("generation-1", "temperature", 0.2)
+	l.Flush()
+
+	logs := ts.logs()
+	for _, want := range []string{
+		`session{id=session-1,action=update,data={"metadata":{"plan":"pro"}}}`,
+		`trace{id=trace-1,action=create,data={"id":"trace-1","metadata":{"seats":3}`,
+		`trace{id=trace-1,action=update,data={"metadata":{"beta":true,"seats":3}}}`,
+		`retrieval{id=retrieval-1,action=update,data={"metadata":{"config":{"rerank":false,"topK":5}}}}`,
+		`generation{id=generation-1,action=update,data={"metadata":{"temperature":0.2}}}`,
+	} {
+		if !strings.Contains(logs, want) {
+			t.Errorf("pushed logs missing %q\n%s", want, logs)
+		}
+	}
+	if len(errs) != 2 || !errors.Is(errs[0], ErrSerializationFailed) || !errors.Is(errs[1], ErrSerializationFailed) {
+		t.Errorf("expected the chan and func metadata to be rejected, got %v", errs)
+	}
+}
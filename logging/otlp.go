@@ -0,0 +1,630 @@
+package logging
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OTLPSinkConfig configures an OTLPSink.
+type OTLPSinkConfig struct {
+	// Endpoint is the OTLP/HTTP traces endpoint. Defaults to
+	// http://localhost:4318/v1/traces.
+	Endpoint string
+	// Headers are added to every export request, e.g. for authentication.
+	Headers map[string]string
+	// ServiceName is reported as the service.name resource attribute.
+	// Defaults to maxim-go.
+	ServiceName string
+	// HTTPClient is used to send export requests. Defaults to a client with
+	// a 10 second timeout.
+	HTTPClient *http.Client
+	// QueueSize is the number of flushed batches waiting to be exported.
+	// Batches flushed while the queue is full are dropped. Defaults to 64.
+	QueueSize int
+	// OpenSpanTimeout is how long an entity may stay open before its span
+	// is exported anyway, ended at that time. Defaults to 1 hour.
+	OpenSpanTimeout time.Duration
+	// OnError receives the failures of background exports. Defaults to
+	// logging them through the SDK logger of the Logger the sink was added
+	// to; they are dropped when the sink was never added to one.
+	OnError func(error)
+}
+
+// OTLPSink converts the commit stream of a Logger into OTLP spans and sends
+// them over OTLP/HTTP (JSON encoding). Traces become root spans, spans become
+// child spans, and generations and retrievals become child spans carrying
+// GenAI semantic-convention attributes.
+//
+// Spans are exported once their entity ends, since OTLP spans cannot be
+// updated after they were sent; commits for entities that already ended are
+// not exported, and neither are children added to them, whose trace is no
+// longer known. Entities open for longer than OpenSpanTimeout are exported
+// as ended at that time.
+//
+// Export only queues the batch: spans are built and sent by a background
+// goroutine, so a slow collector never holds up the flush.
+type OTLPSink struct {
+	config *OTLPSinkConfig
+	client *http.Client
+	mutex  sync.Mutex
+	closed bool
+	// logger is the SDK logger of the first Logger the sink was added to.
+	logger *slog.Logger
+	queue  chan []*CommitLog
+	done   chan struct{}
+	// open is only used by the export goroutine, and by Shutdown once it
+	// has exited.
+	open map[string]*otlpSpanState
+}
+
+type otlpSpanState struct {
+	traceId      string
+	spanId       string
+	parentSpanId string
+	name         string
+	start        time.Time
+	attributes   map[string]interface{}
+	events       []otlpEvent
+	// messages are the generation messages already turned into events.
+	messages     []interface{}
+	errorMessage *string
+	opened       time.Time
+}
+
+var (
+	_ Sink       = (*OTLPSink)(nil)
+	_ loggerSink = (*OTLPSink)(nil)
+)
+
+// NewOTLPSink creates an OTLPSink. Add it to LoggerConfig.Sinks to run it
+// alongside the Maxim push.
+func NewOTLPSink(c *OTLPSinkConfig) *OTLPSink {
+	config := *c
+	if config.Endpoint == "" {
+		config.Endpoint = "http://localhost:4318/v1/traces"
+	}
+	if config.ServiceName == "" {
+		config.ServiceName = "maxim-go"
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = 64
+	}
+	if config.OpenSpanTimeout <= 0 {
+		config.OpenSpanTimeout = time.Hour
+	}
+	client := config.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	s := &OTLPSink{
+		config: &config,
+		client: client,
+		queue:  make(chan []*CommitLog, config.QueueSize),
+		done:   make(chan struct{}),
+		open:   map[string]*otlpSpanState{},
+	}
+	go s.run()
+	return s
+}
+
+// Export queues the commit logs for the background export.
+func (s *OTLPSink) Export(logs []*CommitLog) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.closed {
+		return fmt.Errorf("OTLP sink is shut down, dropping %d logs", len(logs))
+	}
+	select {
+	case s.queue <- logs:
+		return nil
+	default:
+		return fmt.Errorf("%w, dropping %d logs", ErrOTLPQueueFull, len(logs))
+	}
+}
+
+// Shutdown exports the queued batches, then the spans that never ended,
+// ending them now.
+func (s *OTLPSink) Shutdown() error {
+	s.mutex.Lock()
+	if s.closed {
+		s.mutex.Unlock()
+		return nil
+	}
+	s.closed = true
+	close(s.queue)
+	s.mutex.Unlock()
+	<-s.done
+	now := utcNow()
+	spans := make([]otlpSpan, 0, len(s.open))
+	for id, state := range s.open {
+		spans = append(spans, state.toSpan(now))
+		delete(s.open, id)
+	}
+	return s.send(spans)
+}
+
+// run exports the queued batches until the sink is shut down.
+func (s *OTLPSink) run() {
+	defer close(s.done)
+	for logs := range s.queue {
+		if err := s.export(logs); err != nil {
+			s.reportError(err)
+		}
+	}
+}
+
+// export applies the commit logs to the open spans and sends every span that
+// ended in this batch or stayed open for too long.
+func (s *OTLPSink) export(logs []*CommitLog) error {
+	var spans []otlpSpan
+	for _, cl := range logs {
+		if span := s.apply(cl); span != nil {
+			spans = append(spans, *span)
+		}
+	}
+	now := utcNow()
+	for id, state := range s.open {
+		if now.Sub(state.opened) > s.config.OpenSpanTimeout {
+			spans = append(spans, state.toSpan(now))
+			delete(s.open, id)
+		}
+	}
+	return s.send(spans)
+}
+
+func (s *OTLPSink) attachLogger(logger *slog.Logger) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.logger == nil {
+		s.logger = logger
+	}
+}
+
+func (s *OTLPSink) reportError(err error) {
+	if s.config.OnError != nil {
+		s.config.OnError(err)
+		return
+	}
+	s.mutex.Lock()
+	logger := s.logger
+	s.mutex.Unlock()
+	if logger != nil {
+		logger.Error("failed to export OTLP spans", "error", err)
+	}
+}
+
+// apply folds a commit log into the span state of its entity and returns the
+// finished span when the commit ends the entity.
+func (s *OTLPSink) apply(cl *CommitLog) *otlpSpan {
+	data := normalizeCommitData(cl.data)
+	switch cl.action {
+	case "create":
+		if cl.entity != EntityTrace {
+			return nil
+		}
+		state := newOTLPSpanState(cl.entity, cl.entityID, data)
+		state.traceId = otlpTraceId(cl.entityID)
+		if sessionId, ok := data["sessionId"].(string); ok {
+			state.attributes["session.id"] = sessionId
+		}
+		s.open[cl.entityID] = state
+		return nil
+	case "add-span", "add-generation", "add-retrieval":
+		childId, _ := data["id"].(string)
+		if childId == "" {
+			return nil
+		}
+		entity := map[string]Entity{
+			"add-span":       EntitySpan,
+			"add-generation": EntityGeneration,
+			"add-retrieval":  EntityRetrieval,
+		}[cl.action]
+		parent, ok := s.open[cl.entityID]
+		if !ok {
+			// The parent already ended, or was never seen: its trace id is
+			// unknown, so the child cannot be placed in it.
+			return nil
+		}
+		state := newOTLPSpanState(entity, childId, data)
+		state.traceId = parent.traceId
+		state.parentSpanId = parent.spanId
+		s.open[childId] = state
+		return nil
+	}
+	state, ok := s.open[cl.entityID]
+	if !ok {
+		return nil
+	}
+	switch cl.action {
+	case "update":
+		state.update(data)
+	case "add-event":
+		state.addEvent(data)
+	case "add-feedback":
+		if score, ok := data["score"]; ok {
+			state.attributes["maxim.feedback.score"] = score
+		}
+		if comment, ok := data["comment"]; ok {
+			state.attributes["maxim.feedback.comment"] = comment
+		}
+	case "result":
+		state.setResult(data["result"])
+	case "error":
+		state.update(data)
+	case "end":
+		if docs, ok := data["docs"].([]interface{}); ok {
+			state.attributes["maxim.retrieval.documents"] = docs
+		}
+		end := parseCommitTimestamp(data["endTimestamp"])
+		delete(s.open, cl.entityID)
+		span := state.toSpan(end)
+		return &span
+	}
+	return nil
+}
+
+func newOTLPSpanState(entity Entity, id string, data map[string]interface{}) *otlpSpanState {
+	state := &otlpSpanState{
+		spanId: otlpSpanId(id),
+		name:   string(entity),
+		start:  parseCommitTimestamp(data["startTimestamp"]),
+		opened: utcNow(),
+		attributes: map[string]interface{}{
+			"maxim.entity": string(entity),
+			"maxim.id":     id,
+		},
+	}
+	if name, ok := data["name"].(string); ok && name != "" {
+		state.name = name
+	}
+	state.update(data)
+	return state
+}
+
+func (st *otlpSpanState) update(data map[string]interface{}) {
+	for key, value := range data {
+		switch key {
+		case "tags":
+			if tags, ok := value.(map[string]interface{}); ok {
+				for k, v := range tags {
+					st.attributes[k] = v
+				}
+			}
+		case "metadata":
+			if metadata, ok := value.(map[string]interface{}); ok {
+				for k, v := range metadata {
+					st.attributes["maxim.metadata."+k] = v
+				}
+			}
+		case "input":
+			st.attributes["maxim.input"] = value
+		case "output":
+			st.attributes["maxim.output"] = value
+		case "model":
+			st.attributes["gen_ai.request.model"] = value
+		case "provider":
+			st.attributes["gen_ai.system"] = value
+		case "maximPromptId":
+			st.attributes["maxim.prompt.id"] = value
+		case "modelParameters":
+			if params, ok := value.(map[string]interface{}); ok {
+				for k, v := range params {
+					st.attributes["gen_ai.request."+k] = v
+				}
+			}
+		case "messages":
+			if messages, ok := value.([]interface{}); ok {
+				// Generation handles resend all their messages, while
+				// Logger.AddMessageToGeneration sends only the new one.
+				if len(messages) >= len(st.messages) && reflect.DeepEqual(messages[:len(st.messages)], st.messages) {
+					messages = messages[len(st.messages):]
+				}
+				st.messages = append(st.messages, messages...)
+				for _, m := range messages {
+					message, _ := m.(map[string]interface{})
+					role, _ := message["role"].(string)
+					st.events = append(st.events, otlpEvent{
+						TimeUnixNano: otlpTime(utcNow()),
+						Name:         "gen_ai." + role + ".message",
+						Attributes:   otlpAttributes(map[string]interface{}{"content": message["content"]}),
+					})
+				}
+			}
+		case "error":
+			if e, ok := value.(map[string]interface{}); ok {
+				message, _ := e["message"].(string)
+				st.errorMessage = &message
+				if errorType, ok := e["type"]; ok {
+					st.attributes["error.type"] = errorType
+				}
+			}
+		}
+	}
+}
+
+func (st *otlpSpanState) addEvent(data map[string]interface{}) {
+	name, _ := data["name"].(string)
+	timestamp := utcNow()
+	if _, ok := data["timestamp"]; ok {
+		timestamp = parseCommitTimestamp(data["timestamp"])
+	}
+	attributes := map[string]interface{}{}
+	if tags, ok := data["tags"].(map[string]interface{}); ok {
+		attributes = tags
+	}
+	st.events = append(st.events, otlpEvent{
+		TimeUnixNano: otlpTime(timestamp),
+		Name:         name,
+		Attributes:   otlpAttributes(attributes),
+	})
+}
+
+func (st *otlpSpanState) setResult(value interface{}) {
+	result, ok := value.(map[string]interface{})
+	if !ok {
+		return
+	}
+	if id, ok := result["id"]; ok {
+		st.attributes["gen_ai.response.id"] = id
+	}
+	if model, ok := result["model"]; ok {
+		st.attributes["gen_ai.response.model"] = model
+	}
+	if usage, ok := result["usage"].(map[string]interface{}); ok {
+		if v, ok := usage["prompt_tokens"]; ok {
+			st.attributes["gen_ai.usage.input_tokens"] = v
+		}
+		if v, ok := usage["completion_tokens"]; ok {
+			st.attributes["gen_ai.usage.output_tokens"] = v
+		}
+	}
+	if e, ok := result["error"].(map[string]interface{}); ok {
+		message, _ := e["message"].(string)
+		st.errorMessage = &message
+	}
+	choices, _ := result["choices"].([]interface{})
+	var finishReasons []interface{}
+	for _, c := range choices {
+		choice, _ := c.(map[string]interface{})
+		if reason, ok := choice["finish_reason"]; ok {
+			finishReasons = append(finishReasons, reason)
+		}
+		attributes := map[string]interface{}{"index": choice["index"], "finish_reason": choice["finish_reason"]}
+		if text, ok := choice["text"]; ok {
+			attributes["content"] = text
+		}
+		if messages, ok := choice["messages"].([]interface{}); ok && len(messages) > 0 {
+			if message, ok := messages[0].(map[string]interface{}); ok {
+				attributes["content"] = message["content"]
+				attributes["role"] = message["role"]
+			}
+		}
+		st.events = append(st.events, otlpEvent{
+			TimeUnixNano: otlpTime(utcNow()),
+			Name:         "gen_ai.choice",
+			Attributes:   otlpAttributes(attributes),
+		})
+	}
+	if len(finishReasons) > 0 {
+		st.attributes["gen_ai.response.finish_reasons"] = finishReasons
+	}
+}
+
+func (st *otlpSpanState) toSpan(end time.Time) otlpSpan {
+	span := otlpSpan{
+		TraceId:           st.traceId,
+		SpanId:            st.spanId,
+		ParentSpanId:      st.parentSpanId,
+		Name:              st.name,
+		Kind:              1, // SPAN_KIND_INTERNAL
+		StartTimeUnixNano: otlpTime(st.start),
+		EndTimeUnixNano:   otlpTime(end),
+		Attributes:        otlpAttributes(st.attributes),
+		Events:            st.events,
+	}
+	if st.errorMessage != nil {
+		span.Status = &otlpStatus{Code: 2, Message: *st.errorMessage} // STATUS_CODE_ERROR
+	}
+	return span
+}
+
+func (s *OTLPSink) send(spans []otlpSpan) error {
+	if len(spans) == 0 {
+		return nil
+	}
+	body, err := json.Marshal(otlpExportRequest{
+		ResourceSpans: []otlpResourceSpans{{
+			Resource: otlpResource{Attributes: otlpAttributes(map[string]interface{}{
+				"service.name": s.config.ServiceName,
+			})},
+			ScopeSpans: []otlpScopeSpans{{
+				Scope: otlpScope{Name: "github.com/maximhq/maxim-go"},
+				Spans: spans,
+			}},
+		}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode OTLP spans: %w", err)
+	}
+	req, err := http.NewRequest("POST", s.config.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range s.config.Headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to export OTLP spans: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("failed to export OTLP spans: %s", resp.Status)
+	}
+	return nil
+}
+
+// normalizeCommitData turns the data of a commit log into its JSON shape, so
+// typed payloads (Feedback, CompletionRequest, results...) read like maps.
+func normalizeCommitData(data interface{}) map[string]interface{} {
+	normalized := map[string]interface{}{}
+	if data == nil {
+		return normalized
+	}
+	b, err := json.Marshal(data)
+	if err != nil {
+		return normalized
+	}
+	json.Unmarshal(b, &normalized)
+	return normalized
+}
+
+func parseCommitTimestamp(v interface{}) time.Time {
+	if s, ok := v.(string); ok {
+		if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+			return t
+		}
+	}
+	return utcNow()
+}
+
+// otlpTraceId maps a Maxim id to a 16 byte trace id. Ids that already are 16
+// bytes of hex (UUIDs, OpenTelemetry trace ids) are kept so both systems
+// show the same id.
+func otlpTraceId(id string) string {
+	return otlpId(id, 16)
+}
+
+// otlpSpanId maps a Maxim id to an 8 byte span id.
+func otlpSpanId(id string) string {
+	return otlpId(id, 8)
+}
+
+func otlpId(id string, size int) string {
+	raw := strings.ToLower(strings.ReplaceAll(id, "-", ""))
+	if len(raw) == size*2 {
+		if _, err := hex.DecodeString(raw); err == nil {
+			return raw
+		}
+	}
+	sum := sha256.Sum256([]byte(id))
+	return hex.EncodeToString(sum[:size])
+}
+
+func otlpTime(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+// OTLP/JSON wire types, see
+// https://opentelemetry.io/docs/specs/otlp/#json-protobuf-encoding
+
+type otlpExportRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceId           string         `json:"traceId"`
+	SpanId            string         `json:"spanId"`
+	ParentSpanId      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Events            []otlpEvent    `json:"events,omitempty"`
+	Status            *otlpStatus    `json:"status,omitempty"`
+}
+
+type otlpEvent struct {
+	TimeUnixNano string         `json:"timeUnixNano"`
+	Name         string         `json:"name"`
+	Attributes   []otlpKeyValue `json:"attributes,omitempty"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue *string         `json:"stringValue,omitempty"`
+	BoolValue   *bool           `json:"boolValue,omitempty"`
+	IntValue    *string         `json:"intValue,omitempty"`
+	DoubleValue *float64        `json:"doubleValue,omitempty"`
+	ArrayValue  *otlpArrayValue `json:"arrayValue,omitempty"`
+}
+
+type otlpArrayValue struct {
+	Values []otlpAnyValue `json:"values"`
+}
+
+func otlpAttributes(m map[string]interface{}) []otlpKeyValue {
+	attributes := make([]otlpKeyValue, 0, len(m))
+	for key, value := range m {
+		if value == nil {
+			continue
+		}
+		attributes = append(attributes, otlpKeyValue{Key: key, Value: otlpValue(value)})
+	}
+	return attributes
+}
+
+func otlpValue(v interface{}) otlpAnyValue {
+	switch value := v.(type) {
+	case string:
+		return otlpAnyValue{StringValue: &value}
+	case bool:
+		return otlpAnyValue{BoolValue: &value}
+	case float64:
+		if value == math.Trunc(value) && math.Abs(value) < 1<<53 {
+			i := strconv.FormatInt(int64(value), 10)
+			return otlpAnyValue{IntValue: &i}
+		}
+		return otlpAnyValue{DoubleValue: &value}
+	case []interface{}:
+		values := make([]otlpAnyValue, 0, len(value))
+		for _, item := range value {
+			values = append(values, otlpValue(item))
+		}
+		return otlpAnyValue{ArrayValue: &otlpArrayValue{Values: values}}
+	default:
+		b, _ := json.Marshal(value)
+		s := string(b)
+		return otlpAnyValue{StringValue: &s}
+	}
+}
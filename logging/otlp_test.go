@@ -0,0 +1,199 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestOTLPSinkExportsEndedEntities(t *testing.T) {
+	var mutex sync.Mutex
+	var requests []otlpExportRequest
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req otlpExportRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("invalid OTLP payload: %v", err)
+		}
+		mutex.Lock()
+		requests = append(requests, req)
+		mutex.Unlock()
+	}))
+	defer collector.Close()
+
+	l, _ := newTestLogger(t, &LoggerConfig{
+		Sinks: []Sink{NewOTLPSink(&OTLPSinkConfig{Endpoint: collector.URL, ServiceName: "test"})},
+	})
+	traceId := "0af7651916cd43dd8448eb211c80319c"
+	trace := l.Trace(&TraceConfig{Id: traceId, Name: strPtr("request")})
+	span := trace.AddSpan(&SpanConfig{Id: "span-1"})
+	generation := span.AddGeneration(&GenerationConfig{
+		Id:       "generation-1",
+		Provider: "openai",
+		Model:    "gpt-4o",
+		Messages: []CompletionRequest{{Role: "user", Content: "hi"}},
+	})
+	generation.SetResult(&ChatCompletionResult{ID: "cmpl-1", Usage: Usage{PromptTokens: 4, CompletionTokens: 2}})
+	generation.End()
+	span.End()
+	trace.End()
+	l.Cleanup()
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	spans := map[string]otlpSpan{}
+	for _, req := range requests {
+		for _, rs := range req.ResourceSpans {
+			for _, ss := range rs.ScopeSpans {
+				for _, s := range ss.Spans {
+					spans[s.Name] = s
+				}
+			}
+		}
+	}
+	root, ok := spans["request"]
+	if !ok || root.TraceId != traceId || root.ParentSpanId != "" {
+		t.Fatalf("unexpected root span %+v", root)
+	}
+	child := spans["span"]
+	if child.TraceId != traceId || child.ParentSpanId != root.SpanId {
+		t.Errorf("span not parented to trace: %+v", child)
+	}
+	gen := spans["generation"]
+	if gen.ParentSpanId != child.SpanId {
+		t.Errorf("generation not parented to span: %+v", gen)
+	}
+	attributes := map[string]otlpAnyValue{}
+	for _, kv := range gen.Attributes {
+		attributes[kv.Key] = kv.Value
+	}
+	if v := attributes["gen_ai.request.model"]; v.StringValue == nil || *v.StringValue != "gpt-4o" {
+		t.Errorf("missing request model attribute: %+v", gen.Attributes)
+	}
+	if v := attributes["gen_ai.usage.input_tokens"]; v.IntValue == nil || *v.IntValue != "4" {
+		t.Errorf("missing usage attribute: %+v", gen.Attributes)
+	}
+}
+
+func TestOTLPSinkExportDoesNotWaitForCollector(t *testing.T) {
+	release := make(chan struct{})
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer collector.Close()
+	sink := NewOTLPSink(&OTLPSinkConfig{Endpoint: collector.URL, QueueSize: 1})
+	defer sink.Shutdown()
+	defer close(release)
+
+	start := time.Now()
+	var err error
+	for i := 0; i < 10 && err == nil; i++ {
+		err = sink.Export([]*CommitLog{
+			NewCommitLog(EntityTrace, "trace-1", "create", map[string]interface{}{}),
+			NewCommitLog(EntityTrace, "trace-1", "end", map[string]interface{}{}),
+		})
+	}
+	if !errors.Is(err, ErrOTLPQueueFull) {
+		t.Errorf("expected ErrOTLPQueueFull once the queue filled up, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Export waited for the collector for %s", elapsed)
+	}
+}
+
+func TestOTLPSinkBoundsOpenSpans(t *testing.T) {
+	var mutex sync.Mutex
+	var spans []otlpSpan
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req otlpExportRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		mutex.Lock()
+		spans = append(spans, req.ResourceSpans[0].ScopeSpans[0].Spans...)
+		mutex.Unlock()
+	}))
+	defer collector.Close()
+	sink := NewOTLPSink(&OTLPSinkConfig{Endpoint: collector.URL, OpenSpanTimeout: time.Millisecond})
+	defer sink.Shutdown()
+
+	sink.export([]*CommitLog{
+		NewCommitLog(EntityTrace, "trace-1", "create", map[string]interface{}{}),
+		NewCommitLog(EntityTrace, "trace-1", "end", map[string]interface{}{}),
+		NewCommitLog(EntityTrace, "trace-1", "add-span", map[string]interface{}{"id": "late-span"}),
+		NewCommitLog(EntityTrace, "trace-2", "create", map[string]interface{}{}),
+	})
+	if _, ok := sink.open["late-span"]; ok {
+		t.Error("span added to an ended trace was opened with a made-up trace id")
+	}
+	time.Sleep(5 * time.Millisecond)
+	sink.export(nil)
+	if len(sink.open) != 0 {
+		t.Errorf("expected the open trace to expire, %d spans still open", len(sink.open))
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	if len(spans) != 2 {
+		t.Errorf("expected the ended and the expired trace to be exported, got %+v", spans)
+	}
+}
+
+func TestOTLPSinkExportsEachMessageOnce(t *testing.T) {
+	sink := NewOTLPSink(&OTLPSinkConfig{Endpoint: "http://127.0.0.1:0"})
+	defer sink.Shutdown()
+	first := map[string]interface{}{"role": "user", "content": "hi"}
+	second := map[string]interface{}{"role": "assistant", "content": "hello"}
+	sink.export([]*CommitLog{
+		NewCommitLog(EntityTrace, "trace-1", "create", map[string]interface{}{}),
+		NewCommitLog(EntityTrace, "trace-1", "add-generation", map[string]interface{}{"id": "generation-1"}),
+		NewCommitLog(EntityGeneration, "generation-1", "update", map[string]interface{}{"messages": []interface{}{first}}),
+		NewCommitLog(EntityGeneration, "generation-1", "update", map[string]interface{}{"messages": []interface{}{first, second}}),
+		NewCommitLog(EntityGeneration, "generation-1", "update", map[string]interface{}{"messages": []interface{}{first}}),
+	})
+	var names []string
+	for _, event := range sink.open["generation-1"].events {
+		names = append(names, event.Name)
+	}
+	if len(names) != 3 || names[0] != "gen_ai.user.message" || names[1] != "gen_ai.assistant.message" || names[2] != "gen_ai.user.message" {
+		t.Errorf("expected one event per added message, got %v", names)
+	}
+}
+
+func TestOTLPSinkReportsFailuresThroughTheSDKLogger(t *testing.T) {
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer collector.Close()
+	var mutex sync.Mutex
+	var out bytes.Buffer
+	l, _ := newTestLogger(t, &LoggerConfig{
+		Logger: slog.New(slog.NewTextHandler(writerFunc(func(p []byte) (int, error) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			return out.Write(p)
+		}), nil)),
+		Sinks: []Sink{NewOTLPSink(&OTLPSinkConfig{Endpoint: collector.URL})},
+	})
+	l.Trace(&TraceConfig{Id: "trace-1"}).End()
+	l.Cleanup()
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if !strings.Contains(out.String(), "failed to export OTLP spans") {
+		t.Errorf("export failure was not logged through the SDK logger\n%s", out.String())
+	}
+}
+
+type writerFunc func(p []byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) {
+	return f(p)
+}
+
+func strPtr(s string) *string {
+	return &s
+}
@@ -0,0 +1,15 @@
+package logging
+
+// Processor is a step of the commit pipeline. Processors run in order on
+// every commit before it is enqueued; each returns the commit to pass on,
+// possibly rewritten with CommitLog.WithData, and false to veto it.
+type Processor interface {
+	Process(cl *CommitLog) (*CommitLog, bool)
+}
+
+// ProcessorFunc adapts a function to the Processor interface.
+type ProcessorFunc func(cl *CommitLog) (*CommitLog, bool)
+
+func (f ProcessorFunc) Process(cl *CommitLog) (*CommitLog, bool) {
+	return f(cl)
+}
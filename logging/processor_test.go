@@ -0,0 +1,47 @@
+package logging
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestProcessorsEnrichAndVetoCommits(t *testing.T) {
+	addDeployment := ProcessorFunc(func(cl *CommitLog) (*CommitLog, bool) {
+		data, ok := cl.Data().(map[string]interface{})
+		if !ok || cl.Action() != "create" {
+			return cl, true
+		}
+		enriched := map[string]interface{}{"tags": map[string]string{"deployment": "blue"}}
+		for k, v := range data {
+			if k != "tags" {
+				enriched[k] = v
+			}
+		}
+		return cl.WithData(enriched), true
+	})
+	dropRetrievals := ProcessorFunc(func(cl *CommitLog) (*CommitLog, bool) {
+		return cl, cl.Entity() != EntityRetrieval && cl.Action() != "add-retrieval"
+	})
+	l, ts := newTestLogger(t, &LoggerConfig{
+		Redaction:  &RedactionConfig{Detectors: DefaultDetectors()},
+		Processors: []Processor{addDeployment, dropRetrievals},
+	})
+	trace := l.Trace(&TraceConfig{Id: "trace-1"})
+	trace.SetInput("reach me at jane@example.com")
+	retrieval := trace.AddRetrieval(&RetrievalConfig{Id: "retrieval-1"})
+	retrieval.SetInput("query")
+	retrieval.End()
+	trace.End()
+	l.Flush()
+
+	logs := ts.logs()
+	if !strings.Contains(logs, `"tags":{"deployment":"blue"}`) {
+		t.Errorf("create was not enriched\n%s", logs)
+	}
+	if strings.Contains(logs, "retrieval") {
+		t.Errorf("retrieval commits were not vetoed\n%s", logs)
+	}
+	if !strings.Contains(logs, "[REDACTED:email]") {
+		t.Errorf("redaction did not run before processors\n%s", logs)
+	}
+}
@@ -0,0 +1,256 @@
+package logging
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+// Fields of commit data carrying user content, which redaction applies to.
+var redactableFields = []string{"input", "output", "messages", "result", "docs"}
+
+// MaskMode is how a field listed in RedactionConfig.Fields is masked.
+type MaskMode int
+
+const (
+	// MaskRedact replaces the whole field with "[REDACTED]".
+	MaskRedact MaskMode = iota
+	// MaskHash replaces the whole field with the SHA-256 of its content, so
+	// equal values can still be correlated.
+	MaskHash
+	// MaskDrop removes the field from the commit.
+	MaskDrop
+)
+
+// Detector finds sensitive values in text. Matches are replaced with
+// "[REDACTED:<Name>]".
+type Detector struct {
+	Name    string
+	Pattern *regexp.Regexp
+	// Validate, when set, filters out matches that are not actually sensitive
+	// (e.g. digit runs failing the Luhn check).
+	Validate func(match string) bool
+}
+
+// Redactor is a custom redaction step. It receives the name of the field
+// ("input", "output", "messages", "result" or "docs") and every string found
+// in it, and returns the string to log.
+type Redactor func(field, value string) string
+
+// RedactionConfig configures the redaction pipeline run on every commit before
+// it is enqueued.
+type RedactionConfig struct {
+	// Detectors are applied to every string of the redactable fields. Use
+	// DefaultDetectors for emails, phone numbers and card numbers.
+	Detectors []Detector
+	// Redactors run after the detectors.
+	Redactors []Redactor
+	// Fields masks whole fields instead of scanning them, e.g.
+	// {"input": MaskHash}.
+	Fields map[string]MaskMode
+	// DropMessageContent removes the content of generation messages and
+	// results, keeping roles, models, usage and other metadata.
+	DropMessageContent bool
+}
+
+var (
+	emailPattern      = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	creditCardPattern = regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`)
+	phonePattern      = regexp.MustCompile(`\+?\(?\d[\d\s().-]{7,}\d`)
+)
+
+// EmailDetector detects email addresses.
+func EmailDetector() Detector {
+	return Detector{Name: "email", Pattern: emailPattern}
+}
+
+// CreditCardDetector detects card numbers passing the Luhn check.
+func CreditCardDetector() Detector {
+	return Detector{Name: "card", Pattern: creditCardPattern, Validate: luhnValid}
+}
+
+// PhoneDetector detects phone numbers of 10 to 15 digits.
+func PhoneDetector() Detector {
+	return Detector{Name: "phone", Pattern: phonePattern, Validate: func(match string) bool {
+		n := countDigits(match)
+		return n >= 10 && n <= 15
+	}}
+}
+
+// DefaultDetectors returns the built-in detectors. Card numbers are detected
+// before phone numbers, which would otherwise match them.
+func DefaultDetectors() []Detector {
+	return []Detector{EmailDetector(), CreditCardDetector(), PhoneDetector()}
+}
+
+type redactor struct {
+	config RedactionConfig
+}
+
+var _ Processor = (*redactor)(nil)
+
+func newRedactor(c *RedactionConfig) *redactor {
+	return &redactor{config: *c}
+}
+
+// Process returns the commit with its content fields redacted. The original
+// data is left untouched, since it may be shared with entity handles.
+func (r *redactor) Process(cl *CommitLog) (*CommitLog, bool) {
+	return r.redact(cl), true
+}
+
+func (r *redactor) redact(cl *CommitLog) *CommitLog {
+	data, ok := cl.data.(map[string]interface{})
+	if !ok {
+		return cl
+	}
+	var redacted map[string]interface{}
+	for _, field := range redactableFields {
+		value, ok := data[field]
+		if !ok || value == nil {
+			continue
+		}
+		if redacted == nil {
+			redacted = make(map[string]interface{}, len(data))
+			for k, v := range data {
+				redacted[k] = v
+			}
+		}
+		if mode, ok := r.config.Fields[field]; ok {
+			switch mode {
+			case MaskDrop:
+				delete(redacted, field)
+			case MaskHash:
+				redacted[field] = hashValue(value)
+			default:
+				redacted[field] = "[REDACTED]"
+			}
+			continue
+		}
+		if s, ok := value.(string); ok {
+			redacted[field] = r.redactString(field, s)
+			continue
+		}
+		generic := toGeneric(value)
+		if r.config.DropMessageContent && (field == "messages" || field == "result") {
+			dropContent(generic)
+		}
+		redacted[field] = r.walk(field, generic)
+	}
+	if redacted == nil {
+		return cl
+	}
+	return cl.WithData(redacted)
+}
+
+func (r *redactor) walk(field string, v interface{}) interface{} {
+	switch value := v.(type) {
+	case string:
+		return r.redactString(field, value)
+	case []interface{}:
+		for i := range value {
+			value[i] = r.walk(field, value[i])
+		}
+	case map[string]interface{}:
+		for k := range value {
+			value[k] = r.walk(field, value[k])
+		}
+	}
+	return v
+}
+
+func (r *redactor) redactString(field, s string) string {
+	for _, d := range r.config.Detectors {
+		s = d.Pattern.ReplaceAllStringFunc(s, func(match string) string {
+			if d.Validate != nil && !d.Validate(match) {
+				return match
+			}
+			return "[REDACTED:" + d.Name + "]"
+		})
+	}
+	for _, redact := range r.config.Redactors {
+		s = redact(field, s)
+	}
+	return s
+}
+
+// dropContent blanks message contents and completion texts in place.
+func dropContent(v interface{}) {
+	switch value := v.(type) {
+	case []interface{}:
+		for _, item := range value {
+			dropContent(item)
+		}
+	case map[string]interface{}:
+		for k, item := range value {
+			switch k {
+			case "content", "text":
+				value[k] = ""
+			default:
+				dropContent(item)
+			}
+		}
+	}
+}
+
+// toGeneric converts typed values (messages, results...) into their JSON
+// shape, which is also a deep copy.
+func toGeneric(v interface{}) interface{} {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var generic interface{}
+	if err := json.Unmarshal(b, &generic); err != nil {
+		return v
+	}
+	return generic
+}
+
+func hashValue(v interface{}) string {
+	s, ok := v.(string)
+	if !ok {
+		b, _ := json.Marshal(v)
+		s = string(b)
+	}
+	sum := sha256.Sum256([]byte(s))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func countDigits(s string) int {
+	n := 0
+	for _, c := range s {
+		if c >= '0' && c <= '9' {
+			n++
+		}
+	}
+	return n
+}
+
+func luhnValid(s string) bool {
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, s)
+	if len(digits) < 13 || len(digits) > 19 {
+		return false
+	}
+	sum := 0
+	double := false
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
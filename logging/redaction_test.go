@@ -0,0 +1,72 @@
+package logging
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRedactionMasksContentBeforeEnqueue(t *testing.T) {
+	l, ts := newTestLogger(t, &LoggerConfig{
+		Redaction: &RedactionConfig{
+			Detectors: DefaultDetectors(),
+			Redactors: []Redactor{func(field, value string) string {
+				return strings.ReplaceAll(value, "Project Falcon", "[PROJECT]")
+			}},
+			Fields: map[string]MaskMode{"output": MaskHash},
+		},
+	})
+	trace := l.Trace(&TraceConfig{Id: "trace-1"})
+	trace.SetInput("mail jane.doe@example.com or call +1 (415) 555-0132 about Project Falcon")
+	trace.SetOutput("done")
+	messages := []CompletionRequest{{Role: "user", Content: "my card is 4111 1111 1111 1111"}}
+	generation := trace.AddGeneration(&GenerationConfig{Id: "generation-1", Messages: messages})
+	generation.SetResult(map[string]interface{}{"choices": []map[string]interface{}{{"text": "order 1234567 shipped"}}})
+	l.SetRetrievalOutput("retrieval-1", []string{"contact: john@example.org"})
+	l.Flush()
+
+	logs := ts.logs()
+	for _, leaked := range []string{"jane.doe@example.com", "555-0132", "4111", "john@example.org", "Project Falcon", `"output":"done"`} {
+		if strings.Contains(logs, leaked) {
+			t.Errorf("pushed logs leak %q\n%s", leaked, logs)
+		}
+	}
+	for _, want := range []string{
+		`"input":"mail [REDACTED:email] or call [REDACTED:phone] about [PROJECT]"`,
+		`"content":"my card is [REDACTED:card]"`,
+		`"output":"sha256:`,
+		`"text":"order 1234567 shipped"`,
+		`"docs":["contact: [REDACTED:email]"]`,
+	} {
+		if !strings.Contains(logs, want) {
+			t.Errorf("pushed logs missing %q\n%s", want, logs)
+		}
+	}
+	if messages[0].Content != "my card is 4111 1111 1111 1111" {
+		t.Errorf("redaction modified the caller's messages: %v", messages[0].Content)
+	}
+}
+
+func TestRedactionDropsMessageContent(t *testing.T) {
+	l, ts := newTestLogger(t, &LoggerConfig{Redaction: &RedactionConfig{DropMessageContent: true}})
+	l.AddGenerationToTrace("trace-1", &GenerationConfig{
+		Id:       "generation-1",
+		Model:    "gpt-4o",
+		Messages: []CompletionRequest{{Role: "user", Content: "secret question"}},
+	})
+	content := "secret answer"
+	l.AddResultToGeneration("generation-1", &ChatCompletionResult{
+		Choices: []ChatCompletionChoice{{Messages: []ChatCompletionMessage{{Role: "assistant", Content: &content}}}},
+		Usage:   Usage{TotalTokens: 12},
+	})
+	l.Flush()
+
+	logs := ts.logs()
+	if strings.Contains(logs, "secret") {
+		t.Errorf("message content was not dropped\n%s", logs)
+	}
+	for _, want := range []string{`"content":"","role":"user"`, `"model":"gpt-4o"`, `"total_tokens":12`} {
+		if !strings.Contains(logs, want) {
+			t.Errorf("pushed logs missing %q\n%s", want, logs)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package logging
+
+import (
+	"os"
+	"runtime"
+
+	"github.com/maximhq/maxim-go/internal"
+)
+
+// Keys of the tags returned by ResourceTags.
+const (
+	TagHostName   = "host.name"
+	TagGoVersion  = "go.version"
+	TagSDKVersion = "maxim.sdk.version"
+)
+
+// ResourceTags returns tags describing the running process: the host name,
+// the Go version and the SDK version.
+func ResourceTags() map[string]string {
+	tags := map[string]string{
+		TagGoVersion:  runtime.Version(),
+		TagSDKVersion: internal.SDKVersion,
+	}
+	if hostname, err := os.Hostname(); err == nil {
+		tags[TagHostName] = hostname
+	}
+	return tags
+}
+
+// mergeTags returns the entity tags on top of the default ones. The entity
+// tags are returned as is when there are no defaults.
+func mergeTags(defaults map[string]string, tags *map[string]string) *map[string]string {
+	if len(defaults) == 0 {
+		return tags
+	}
+	merged := make(map[string]string, len(defaults))
+	for key, value := range defaults {
+		merged[key] = value
+	}
+	if tags != nil {
+		for key, value := range *tags {
+			merged[key] = value
+		}
+	}
+	return &merged
+}
+
+func copyTags(tags map[string]string) map[string]string {
+	c := make(map[string]string, len(tags))
+	for key, value := range tags {
+		c[key] = value
+	}
+	return c
+}
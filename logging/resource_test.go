@@ -0,0 +1,55 @@
+package logging
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestDefaultTagsMergedIntoEntities(t *testing.T) {
+	l, ts := newTestLogger(t, &LoggerConfig{
+		DefaultTags:    map[string]string{"env": "prod", "service": "search"},
+		DetectResource: true,
+	})
+	trace := l.Trace(&TraceConfig{Id: "trace-1", Tags: &map[string]string{"service": "ranker"}})
+	trace.AddSpan(&SpanConfig{Id: "span-1"})
+	l.Flush()
+
+	logs := ts.logs()
+	for _, want := range []string{
+		`"env":"prod"`,
+		`"service":"ranker"`,
+		`"go.version":"` + runtime.Version() + `"`,
+	} {
+		if !strings.Contains(logs, want) {
+			t.Errorf("pushed logs missing %q\n%s", want, logs)
+		}
+	}
+	if n := strings.Count(logs, `"service":"search"`); n != 1 {
+		t.Errorf("expected the default service tag only on the span, found %d\n%s", n, logs)
+	}
+	if n := strings.Count(logs, `"env":"prod"`); n != 2 {
+		t.Errorf("expected default tags on the trace and the span, found %d\n%s", n, logs)
+	}
+}
+
+func TestDefaultTagsCommittedForSessions(t *testing.T) {
+	l, ts := newTestLogger(t, &LoggerConfig{
+		DefaultTags:    map[string]string{"env": "prod"},
+		DetectResource: true,
+	})
+	l.Session(&SessionConfig{Id: "session-1", Tags: &map[string]string{"tenant": "acme"}})
+	l.Flush()
+
+	logs := ts.logs()
+	for _, want := range []string{
+		`session{id=session-1,action=update`,
+		`"env":"prod"`,
+		`"tenant":"acme"`,
+		`"go.version":"` + runtime.Version() + `"`,
+	} {
+		if !strings.Contains(logs, want) {
+			t.Errorf("pushed logs missing %q\n%s", want, logs)
+		}
+	}
+}
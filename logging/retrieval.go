@@ -1,10 +1,15 @@
 package logging
 
+import "time"
+
 type RetrievalConfig struct {
-	Id     string             `json:"id"`
-	SpanId *string            `json:"spanId,omitempty"`
-	Name   *string            `json:"name,omitempty"`
-	Tags   *map[string]string `json:"tags,omitempty"`
+	Id             string                 `json:"id"`
+	SpanId         *string                `json:"spanId,omitempty"`
+	Name           *string                `json:"name,omitempty"`
+	Tags           *map[string]string     `json:"tags,omitempty"`
+	StartTimestamp *time.Time             `json:"startTimestamp,omitempty"`
+	EndTimestamp   *time.Time             `json:"endTimestamp,omitempty"`
+	Metadata       map[string]interface{} `json:"metadata,omitempty"`
 }
 
 type Retrieval struct {
@@ -12,12 +17,16 @@ type Retrieval struct {
 }
 
 func newRetrieval(c *RetrievalConfig, w *writer) *Retrieval {
+	w.assignId(EntityRetrieval, &c.Id)
 	return &Retrieval{
 		base: newBase(EntityRetrieval, c.Id, &baseConfig{
-			Id:     c.Id,
-			SpanId: c.SpanId,
-			Name:   c.Name,
-			Tags:   c.Tags,
+			Id:             c.Id,
+			SpanId:         c.SpanId,
+			Name:           c.Name,
+			Tags:           c.Tags,
+			StartTimestamp: c.StartTimestamp,
+			EndTimestamp:   c.EndTimestamp,
+			Metadata:       c.Metadata,
 		}, w),
 	}
 }
@@ -28,9 +37,21 @@ func (r *Retrieval) SetInput(query string) {
 	})
 }
 
+// SetOutput records the retrieved documents and ends the retrieval.
 func (r *Retrieval) SetOutput(docs []string) {
-	r.commit("end", map[string]interface{}{
+	if !r.accept("end") {
+		return
+	}
+	endTimestamp := r.endTime().UTC()
+	r.setEndTimestamp(endTimestamp)
+	r.send("end", map[string]interface{}{
 		"docs":         docs,
-		"endTimestamp": utcNow(),
+		"endTimestamp": endTimestamp,
 	})
 }
+
+// SetError records the error on the retrieval, e.g. a failed vector search,
+// and marks it as failed.
+func (r *Retrieval) SetError(err error) {
+	r.recordError(err)
+}
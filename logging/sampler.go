@@ -0,0 +1,73 @@
+package logging
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"math"
+)
+
+// Sampler decides whether a trace is recorded. It is evaluated once, when
+// the trace is created through Logger.Trace, Logger.SessionAddTrace or
+// Session.AddTrace. Unsampled traces return handles that commit nothing, and
+// so do the spans, generations and retrievals added to them.
+type Sampler interface {
+	ShouldSample(c *TraceConfig) bool
+}
+
+// SamplerFunc adapts a function to the Sampler interface.
+type SamplerFunc func(c *TraceConfig) bool
+
+func (f SamplerFunc) ShouldSample(c *TraceConfig) bool {
+	return f(c)
+}
+
+// AlwaysSample records every trace.
+func AlwaysSample() Sampler {
+	return SamplerFunc(func(*TraceConfig) bool { return true })
+}
+
+// NeverSample records no trace.
+func NeverSample() Sampler {
+	return SamplerFunc(func(*TraceConfig) bool { return false })
+}
+
+// TraceIdRatioSampler records the given fraction of traces. The decision is a
+// hash of the trace id, so every service sampling the same trace id with the
+// same ratio makes the same decision.
+func TraceIdRatioSampler(ratio float64) Sampler {
+	if ratio >= 1 {
+		return AlwaysSample()
+	}
+	if ratio <= 0 {
+		return NeverSample()
+	}
+	threshold := uint64(ratio * math.MaxUint64)
+	return SamplerFunc(func(c *TraceConfig) bool {
+		sum := sha256.Sum256([]byte(c.Id))
+		return binary.BigEndian.Uint64(sum[:8]) < threshold
+	})
+}
+
+// TagRule delegates the sampling decision for traces tagged with Key to
+// Sampler. An empty Value matches any value of the tag.
+type TagRule struct {
+	Key     string
+	Value   string
+	Sampler Sampler
+}
+
+// TagRuleSampler decides with the first rule matching the trace's tags, and
+// with fallback when no rule matches.
+func TagRuleSampler(rules []TagRule, fallback Sampler) Sampler {
+	return SamplerFunc(func(c *TraceConfig) bool {
+		if c.Tags != nil {
+			for _, rule := range rules {
+				value, ok := (*c.Tags)[rule.Key]
+				if ok && (rule.Value == "" || rule.Value == value) {
+					return rule.Sampler.ShouldSample(c)
+				}
+			}
+		}
+		return fallback.ShouldSample(c)
+	})
+}
@@ -0,0 +1,71 @@
+package logging
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestUnsampledTracesCommitNothing(t *testing.T) {
+	var leaked []LeakedEntity
+	l, ts := newTestLogger(t, &LoggerConfig{
+		Sampler:       TagRuleSampler([]TagRule{{Key: "debug", Sampler: AlwaysSample()}}, NeverSample()),
+		LeakDetection: &LeakDetectionConfig{OnLeak: func(e LeakedEntity) { leaked = append(leaked, e) }},
+	})
+	dropped := l.Trace(&TraceConfig{Id: "dropped"})
+	span := dropped.AddSpan(&SpanConfig{Id: "dropped-span"})
+	span.AddGeneration(&GenerationConfig{Id: "dropped-generation"}).SetModel("gpt-4o")
+	l.AddRetrievalToSpan("dropped-span", &RetrievalConfig{Id: "dropped-retrieval"})
+	l.SetRetrievalInput("dropped-retrieval", "query")
+	l.AddTagToTrace("dropped", "key", "value")
+	span.End()
+	dropped.End()
+
+	kept := l.Trace(&TraceConfig{Id: "kept", Tags: &map[string]string{"debug": "true"}})
+	kept.AddSpan(&SpanConfig{Id: "kept-span"}).End()
+	kept.End()
+	l.Flush()
+
+	if dropped.Sampled() || !kept.Sampled() {
+		t.Fatalf("unexpected sampling decisions")
+	}
+	logs := ts.logs()
+	if strings.Contains(logs, "dropped") {
+		t.Errorf("unsampled trace was committed\n%s", logs)
+	}
+	if !strings.Contains(logs, "trace{id=kept,action=add-span") {
+		t.Errorf("sampled trace missing\n%s", logs)
+	}
+	if n := countUnsampled(l); n != 0 {
+		// The generation and retrieval were never ended, but their trace was.
+		t.Errorf("expected no unsampled ids left once the trace ended, got %d", n)
+	}
+	l.writer.leaks.check(l.writer, utcNow(), true)
+	if len(leaked) != 0 {
+		t.Errorf("unsampled entities were registered with the leak detector: %+v", leaked)
+	}
+}
+
+func TestTraceIdRatioSamplerIsDeterministic(t *testing.T) {
+	sampler := TraceIdRatioSampler(0.25)
+	kept := 0
+	for i := 0; i < 10000; i++ {
+		c := &TraceConfig{Id: fmt.Sprintf("trace-%d", i)}
+		decision := sampler.ShouldSample(c)
+		if decision != sampler.ShouldSample(c) {
+			t.Fatalf("sampler is not deterministic for %s", c.Id)
+		}
+		if decision {
+			kept++
+		}
+	}
+	if kept < 2200 || kept > 2800 {
+		t.Errorf("expected about 2500 sampled traces, got %d", kept)
+	}
+}
+
+func countUnsampled(l *Logger) int {
+	l.writer.unsampledMutex.Lock()
+	defer l.writer.unsampledMutex.Unlock()
+	return len(l.writer.unsampled)
+}
@@ -0,0 +1,123 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+)
+
+// SpanParent is an entity spans can be added to: a Trace or a Span.
+type SpanParent interface {
+	AddSpan(c *SpanConfig) *Span
+}
+
+// GenerationParent is an entity generations can be added to: a Trace or a
+// Span.
+type GenerationParent interface {
+	AddGeneration(c *GenerationConfig) *Generation
+}
+
+var (
+	_ SpanParent       = (*Trace)(nil)
+	_ SpanParent       = (*Span)(nil)
+	_ GenerationParent = (*Trace)(nil)
+	_ GenerationParent = (*Span)(nil)
+)
+
+// WithSpan adds a span to parent, or to the span or trace carried by ctx
+// when parent is nil (including a nil *Trace or *Span), and calls fn with it and a context carrying it. The
+// error returned by fn, or the value of a panic, is recorded on the span,
+// which is then ended. Panics are propagated once the span is ended.
+func WithSpan(ctx context.Context, parent SpanParent, c *SpanConfig, fn func(ctx context.Context, s *Span) error) (err error) {
+	if isNilParent(parent) {
+		parent = spanParentFromContext(ctx)
+	}
+	if parent == nil {
+		return fmt.Errorf("%w for span %s", ErrNoParent, c.Id)
+	}
+	if t, ok := parent.(*Trace); ok {
+		ctx = ContextWithTrace(ctx, t)
+	}
+	s := parent.AddSpan(c)
+	defer func() {
+		if r := recover(); r != nil {
+			s.SetError(panicError(r))
+			s.End()
+			panic(r)
+		}
+		if err != nil {
+			s.SetError(err)
+		}
+		s.End()
+	}()
+	return fn(ContextWithSpan(ctx, s), s)
+}
+
+// WithGeneration adds a generation to parent, or to the span or trace
+// carried by ctx when parent is nil (including a nil *Trace or *Span), and
+// calls fn with it and a context
+// carrying it. The error returned by fn, or the value of a panic, is set as
+// the generation error, and the generation is then ended. Panics are
+// propagated once the generation is ended.
+func WithGeneration(ctx context.Context, parent GenerationParent, c *GenerationConfig, fn func(ctx context.Context, g *Generation) error) (err error) {
+	if isNilParent(parent) {
+		parent = generationParentFromContext(ctx)
+	}
+	if parent == nil {
+		return fmt.Errorf("%w for generation %s", ErrNoParent, c.Id)
+	}
+	g := parent.AddGeneration(c)
+	defer func() {
+		if r := recover(); r != nil {
+			g.RecordError(panicError(r))
+			g.End()
+			panic(r)
+		}
+		if err != nil {
+			g.RecordError(err)
+		}
+		g.End()
+	}()
+	return fn(ContextWithGeneration(ctx, g), g)
+}
+
+// isNilParent reports whether parent is nil or holds a nil *Trace or *Span,
+// which would otherwise pass a plain nil check.
+func isNilParent(parent interface{}) bool {
+	switch p := parent.(type) {
+	case nil:
+		return true
+	case *Trace:
+		return p == nil
+	case *Span:
+		return p == nil
+	}
+	return false
+}
+
+func spanParentFromContext(ctx context.Context) SpanParent {
+	if s := SpanFromContext(ctx); s != nil {
+		return s
+	}
+	if t := TraceFromContext(ctx); t != nil {
+		return t
+	}
+	return nil
+}
+
+func generationParentFromContext(ctx context.Context) GenerationParent {
+	if s := SpanFromContext(ctx); s != nil {
+		return s
+	}
+	if t := TraceFromContext(ctx); t != nil {
+		return t
+	}
+	return nil
+}
+
+// panicError turns a recovered value into an error.
+func panicError(r interface{}) error {
+	if err, ok := r.(error); ok {
+		return fmt.Errorf("panic: %w", err)
+	}
+	return fmt.Errorf("panic: %v", r)
+}
@@ -0,0 +1,100 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestWithSpan(t *testing.T) {
+	l, ts := newTestLogger(t, nil)
+	trace := l.Trace(&TraceConfig{Id: "trace-1"})
+	errSearch := errors.New("index unavailable")
+	var inner *Span
+	err := WithSpan(context.Background(), trace, &SpanConfig{Id: "span-1"}, func(ctx context.Context, s *Span) error {
+		if TraceFromContext(ctx) != trace || SpanFromContext(ctx) != s {
+			t.Error("expected the trace and span in the context")
+		}
+		return WithSpan(ctx, nil, &SpanConfig{Id: "span-2"}, func(ctx context.Context, s *Span) error {
+			inner = s
+			return errSearch
+		})
+	})
+	if !errors.Is(err, errSearch) {
+		t.Fatalf("expected the error of fn, got %v", err)
+	}
+	if !inner.Ended() {
+		t.Error("expected the inner span to be ended")
+	}
+	l.Flush()
+	logs := ts.logs()
+	for _, want := range []string{
+		`span{id=span-1,action=add-span,data={"id":"span-2"`,
+		`span{id=span-2,action=update,data={"error":{"message":"index unavailable","type":"*errors.errorString"},"status":"failed"}}`,
+		"span{id=span-1,action=end",
+	} {
+		if !strings.Contains(logs, want) {
+			t.Errorf("pushed logs missing %q\n%s", want, logs)
+		}
+	}
+}
+
+func TestWithGenerationRecordsPanics(t *testing.T) {
+	l, ts := newTestLogger(t, nil)
+	trace := l.Trace(&TraceConfig{Id: "trace-1"})
+	ctx := ContextWithTrace(context.Background(), trace)
+	var generation *Generation
+	func() {
+		defer func() {
+			if r := recover(); r != "provider exploded" {
+				t.Errorf("expected the panic to be propagated, got %v", r)
+			}
+		}()
+		WithGeneration(ctx, nil, &GenerationConfig{Id: "generation-1"}, func(ctx context.Context, g *Generation) error {
+			generation = g
+			panic("provider exploded")
+		})
+	}()
+	if !generation.Ended() {
+		t.Error("expected the generation to be ended")
+	}
+	l.Flush()
+	if want := `"error":{"message":"panic: provider exploded"`; !strings.Contains(ts.logs(), want) {
+		t.Errorf("pushed logs missing %q\n%s", want, ts.logs())
+	}
+}
+
+func TestWithSpanWithoutParent(t *testing.T) {
+	err := WithSpan(context.Background(), nil, &SpanConfig{Id: "span-1"}, func(ctx context.Context, s *Span) error {
+		t.Error("fn called without a parent")
+		return nil
+	})
+	if !errors.Is(err, ErrNoParent) {
+		t.Errorf("expected ErrNoParent, got %v", err)
+	}
+}
+
+func TestWithSpanWithNilTypedParent(t *testing.T) {
+	l, ts := newTestLogger(t, nil)
+	trace := l.Trace(&TraceConfig{Id: "trace-1"})
+	var parent *Span
+	err := WithSpan(ContextWithTrace(context.Background(), trace), parent, &SpanConfig{Id: "span-1"}, func(ctx context.Context, s *Span) error {
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var noParent *Trace
+	err = WithGeneration(context.Background(), noParent, &GenerationConfig{Id: "generation-1"}, func(ctx context.Context, g *Generation) error {
+		t.Error("fn called without a parent")
+		return nil
+	})
+	if !errors.Is(err, ErrNoParent) {
+		t.Errorf("expected ErrNoParent, got %v", err)
+	}
+	l.Flush()
+	if !strings.Contains(ts.logs(), "trace{id=trace-1,action=add-span") {
+		t.Errorf("span was not added to the trace from the context\n%s", ts.logs())
+	}
+}
@@ -1,9 +1,14 @@
 package logging
 
+import "time"
+
 type SessionConfig struct {
-	Id   string             `json:"id"`
-	Name *string            `json:"name,omitempty"`
-	Tags *map[string]string `json:"tags,omitempty"`
+	Id             string                 `json:"id"`
+	Name           *string                `json:"name,omitempty"`
+	Tags           *map[string]string     `json:"tags,omitempty"`
+	StartTimestamp *time.Time             `json:"startTimestamp,omitempty"`
+	EndTimestamp   *time.Time             `json:"endTimestamp,omitempty"`
+	Metadata       map[string]interface{} `json:"metadata,omitempty"`
 }
 
 type Session struct {
@@ -11,13 +16,31 @@ type Session struct {
 }
 
 func newSession(c *SessionConfig, w *writer) *Session {
-	return &Session{
+	w.assignId(EntitySession, &c.Id)
+	s := &Session{
 		base: newBase(EntitySession, c.Id, &baseConfig{
-			Id:   c.Id,
-			Name: c.Name,
-			Tags: c.Tags,
+			Id:             c.Id,
+			Name:           c.Name,
+			Tags:           c.Tags,
+			StartTimestamp: c.StartTimestamp,
+			EndTimestamp:   c.EndTimestamp,
+			Metadata:       c.Metadata,
 		}, w),
 	}
+	s.skipIfUnsampled("")
+	// Sessions are not committed on creation, so their initial tags,
+	// defaults included, and metadata are sent as an update.
+	update := map[string]interface{}{}
+	if s.tags != nil && len(*s.tags) > 0 {
+		update["tags"] = copyTags(*s.tags)
+	}
+	if len(s.metadata) > 0 {
+		update["metadata"] = copyMetadata(s.metadata)
+	}
+	if len(update) > 0 {
+		s.commit("update", update)
+	}
+	return s
 }
 
 func (s *Session) Feedback(f *Feedback) {
@@ -26,5 +49,9 @@ func (s *Session) Feedback(f *Feedback) {
 
 func (s *Session) AddTrace(c *TraceConfig) *Trace {
 	c.SessionId = &s.id
-	return newTrace(c, s.writer)
+	t := newTrace(c, s.writer)
+	if t.Sampled() {
+		s.addChild(t.base)
+	}
+	return t
 }
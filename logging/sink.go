@@ -0,0 +1,20 @@
+package logging
+
+import "log/slog"
+
+// Sink receives every batch of commit logs flushed by a Logger. Sinks run
+// alongside the push to Maxim; a failing sink never blocks the push.
+type Sink interface {
+	// Export is called from the flush loop with the logs about to be pushed.
+	// It must not block: sinks doing I/O hand the batch off, as OTLPSink
+	// does.
+	Export(logs []*CommitLog) error
+	// Shutdown is called once when the logger is cleaned up.
+	Shutdown() error
+}
+
+// loggerSink is implemented by the sinks of this package that report
+// background failures through the SDK logger of the Logger they run in.
+type loggerSink interface {
+	attachLogger(logger *slog.Logger)
+}
@@ -0,0 +1,137 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+)
+
+// SlogHandlerOptions configures a SlogHandler.
+type SlogHandlerOptions struct {
+	// Level is the minimum level recorded as a Maxim event. Defaults to
+	// slog.LevelInfo.
+	Level slog.Leveler
+	// AlsoDelegate passes records recorded as Maxim events to the wrapped
+	// handler as well, instead of only those logged outside a trace.
+	AlsoDelegate bool
+}
+
+// SlogHandler is a slog.Handler that records log lines as events on the Maxim
+// span or trace carried by the context (see ContextWithSpan and
+// ContextWithTrace). The record message becomes the event name, and the
+// level and attributes become event tags. Records logged without a Maxim
+// entity in the context are passed to the wrapped handler.
+type SlogHandler struct {
+	next   slog.Handler
+	opts   SlogHandlerOptions
+	attrs  map[string]string
+	groups []string
+}
+
+var _ slog.Handler = (*SlogHandler)(nil)
+
+// NewSlogHandler creates a SlogHandler delegating to next.
+func NewSlogHandler(next slog.Handler, opts *SlogHandlerOptions) *SlogHandler {
+	h := &SlogHandler{
+		next:  next,
+		attrs: map[string]string{},
+	}
+	if opts != nil {
+		h.opts = *opts
+	}
+	if h.opts.Level == nil {
+		h.opts.Level = slog.LevelInfo
+	}
+	return h
+}
+
+func (h *SlogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	if eventEmitterFromContext(ctx) != nil && level >= h.opts.Level.Level() {
+		return true
+	}
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *SlogHandler) Handle(ctx context.Context, r slog.Record) error {
+	emitter := eventEmitterFromContext(ctx)
+	if emitter == nil || r.Level < h.opts.Level.Level() {
+		if !h.next.Enabled(ctx, r.Level) {
+			return nil
+		}
+		return h.next.Handle(ctx, r)
+	}
+	tags := make(map[string]string, len(h.attrs)+r.NumAttrs()+1)
+	for key, value := range h.attrs {
+		tags[key] = value
+	}
+	prefix := h.groupPrefix()
+	r.Attrs(func(a slog.Attr) bool {
+		flattenAttr(tags, prefix, a)
+		return true
+	})
+	tags["level"] = r.Level.String()
+	emitter.addEventAt(NewID(), r.Message, &tags, r.Time)
+	if h.opts.AlsoDelegate && h.next.Enabled(ctx, r.Level) {
+		return h.next.Handle(ctx, r)
+	}
+	return nil
+}
+
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := h.clone()
+	clone.next = h.next.WithAttrs(attrs)
+	prefix := h.groupPrefix()
+	for _, a := range attrs {
+		flattenAttr(clone.attrs, prefix, a)
+	}
+	return clone
+}
+
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	clone := h.clone()
+	clone.next = h.next.WithGroup(name)
+	clone.groups = append(clone.groups, name)
+	return clone
+}
+
+func (h *SlogHandler) clone() *SlogHandler {
+	attrs := make(map[string]string, len(h.attrs))
+	for key, value := range h.attrs {
+		attrs[key] = value
+	}
+	return &SlogHandler{
+		next:   h.next,
+		opts:   h.opts,
+		attrs:  attrs,
+		groups: append([]string(nil), h.groups...),
+	}
+}
+
+func (h *SlogHandler) groupPrefix() string {
+	if len(h.groups) == 0 {
+		return ""
+	}
+	return strings.Join(h.groups, ".") + "."
+}
+
+// flattenAttr adds the attribute to tags, flattening groups into dotted keys.
+func flattenAttr(tags map[string]string, prefix string, a slog.Attr) {
+	value := a.Value.Resolve()
+	if value.Kind() == slog.KindGroup {
+		groupPrefix := prefix
+		if a.Key != "" {
+			groupPrefix = prefix + a.Key + "."
+		}
+		for _, ga := range value.Group() {
+			flattenAttr(tags, groupPrefix, ga)
+		}
+		return
+	}
+	if a.Key == "" {
+		return
+	}
+	tags[prefix+a.Key] = value.String()
+}
@@ -0,0 +1,61 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSlogHandlerRecordsEventsOnContextEntity(t *testing.T) {
+	l, ts := newTestLogger(t, nil)
+	var out bytes.Buffer
+	logger := slog.New(NewSlogHandler(slog.NewTextHandler(&out, nil), nil)).
+		With("service", "chat").
+		WithGroup("req")
+
+	trace := l.Trace(&TraceConfig{Id: "trace-1"})
+	span := trace.AddSpan(&SpanConfig{Id: "span-1"})
+	ctx := ContextWithSpan(ContextWithTrace(context.Background(), trace), span)
+
+	logger.InfoContext(ctx, "calling model", "attempt", 2)
+	logger.DebugContext(ctx, "below level")
+	logger.InfoContext(context.Background(), "outside trace")
+	l.Flush()
+
+	logs := ts.logs()
+	want := `span{id=span-1,action=add-event,data={"id":`
+	if !strings.Contains(logs, want) {
+		t.Fatalf("pushed logs missing %q\n%s", want, logs)
+	}
+	for _, want := range []string{`"name":"calling model"`, `"level":"INFO"`, `"req.attempt":"2"`, `"service":"chat"`} {
+		if !strings.Contains(logs, want) {
+			t.Errorf("pushed logs missing %q\n%s", want, logs)
+		}
+	}
+	if strings.Contains(logs, "below level") || strings.Contains(logs, "outside trace") {
+		t.Errorf("unexpected records recorded as events\n%s", logs)
+	}
+	if !strings.Contains(out.String(), "outside trace") || strings.Contains(out.String(), "calling model") {
+		t.Errorf("unexpected delegated output: %s", out.String())
+	}
+}
+
+func TestSlogHandlerKeepsRecordTime(t *testing.T) {
+	l, ts := newTestLogger(t, nil)
+	handler := NewSlogHandler(slog.NewTextHandler(&bytes.Buffer{}, nil), nil)
+	trace := l.Trace(&TraceConfig{Id: "trace-1"})
+	ctx := ContextWithTrace(context.Background(), trace)
+
+	at := time.Date(2024, 1, 1, 12, 0, 0, 0, time.FixedZone("CET", 3600))
+	if err := handler.Handle(ctx, slog.NewRecord(at, slog.LevelInfo, "buffered", 0)); err != nil {
+		t.Fatal(err)
+	}
+	l.Flush()
+
+	if want := `"timestamp":"2024-01-01T11:00:00Z"`; !strings.Contains(ts.logs(), want) {
+		t.Errorf("pushed logs missing %q\n%s", want, ts.logs())
+	}
+}
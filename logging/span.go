@@ -1,10 +1,15 @@
 package logging
 
+import "time"
+
 type SpanConfig struct {
-	Id     string             `json:"id"`
-	SpanId *string            `json:"spanId,omitempty"`
-	Name   *string            `json:"name,omitempty"`
-	Tags   *map[string]string `json:"tags,omitempty"`
+	Id             string                 `json:"id"`
+	SpanId         *string                `json:"spanId,omitempty"`
+	Name           *string                `json:"name,omitempty"`
+	Tags           *map[string]string     `json:"tags,omitempty"`
+	StartTimestamp *time.Time             `json:"startTimestamp,omitempty"`
+	EndTimestamp   *time.Time             `json:"endTimestamp,omitempty"`
+	Metadata       map[string]interface{} `json:"metadata,omitempty"`
 }
 
 type Span struct {
@@ -12,13 +17,17 @@ type Span struct {
 }
 
 func newSpan(c *SpanConfig, w *writer) *Span {
+	w.assignId(EntitySpan, &c.Id)
 	return &Span{
 		eventEmitter: &eventEmitter{
 			base: newBase(EntitySpan, c.Id, &baseConfig{
-				Id:     c.Id,
-				SpanId: c.SpanId,
-				Name:   c.Name,
-				Tags:   c.Tags,
+				Id:             c.Id,
+				SpanId:         c.SpanId,
+				Name:           c.Name,
+				Tags:           c.Tags,
+				StartTimestamp: c.StartTimestamp,
+				EndTimestamp:   c.EndTimestamp,
+				Metadata:       c.Metadata,
 			}, w),
 		},
 	}
@@ -26,6 +35,10 @@ func newSpan(c *SpanConfig, w *writer) *Span {
 
 func (s *Span) AddGeneration(c *GenerationConfig) *Generation {
 	g := newGeneration(c, s.writer)
+	if g.skipIfUnsampled(s.unsampledTrace) {
+		return g
+	}
+	s.addChild(g.base)
 	genData := g.data()
 	genData["id"] = c.Id
 	s.commit("add-generation", genData)
@@ -34,16 +47,52 @@ func (s *Span) AddGeneration(c *GenerationConfig) *Generation {
 
 func (s *Span) AddSubSpan(c *SpanConfig) *Span {
 	subSpan := newSpan(c, s.writer)
+	if subSpan.skipIfUnsampled(s.unsampledTrace) {
+		return subSpan
+	}
+	s.addChild(subSpan.base)
 	spanData := subSpan.data()
 	spanData["id"] = c.Id
 	s.commit("add-span", spanData)
 	return subSpan
 }
 
+// AddSpan is AddSubSpan, so that spans and traces both are SpanParents.
+func (s *Span) AddSpan(c *SpanConfig) *Span {
+	return s.AddSubSpan(c)
+}
+
 func (s *Span) AddRetrieval(c *RetrievalConfig) *Retrieval {
 	r := newRetrieval(c, s.writer)
+	if r.skipIfUnsampled(s.unsampledTrace) {
+		return r
+	}
+	s.addChild(r.base)
 	rData := r.data()
 	rData["id"] = c.Id
 	s.commit("add-retrieval", rData)
 	return r
 }
+
+// SetInput sets the input of the span: a string, or any JSON serializable
+// value such as a struct or a json.RawMessage.
+func (s *Span) SetInput(i interface{}) *Span {
+	s.commit("update", map[string]interface{}{
+		"input": i,
+	})
+	return s
+}
+
+// SetOutput sets the output of the span, like SetInput.
+func (s *Span) SetOutput(o interface{}) *Span {
+	s.commit("update", map[string]interface{}{
+		"output": o,
+	})
+	return s
+}
+
+// SetError records the error on the span and marks it as failed.
+func (s *Span) SetError(err error) *Span {
+	s.recordError(err)
+	return s
+}
@@ -0,0 +1,138 @@
+package logging
+
+import (
+	"expvar"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// LoggerStats is a point-in-time snapshot of the health of a Logger.
+type LoggerStats struct {
+	// RepoId is the log repository of the logger.
+	RepoId string `json:"repoId"`
+	// QueueDepth is the number of commits waiting for the next flush.
+	QueueDepth int `json:"queueDepth"`
+	// Committed is the total number of commits accepted into the queue.
+	Committed uint64 `json:"committed"`
+	// CommitsPerSecond is the commit rate measured over the last flush interval.
+	CommitsPerSecond float64 `json:"commitsPerSecond"`
+	// Flushes is the number of flushes that pushed at least one log.
+	Flushes uint64 `json:"flushes"`
+	// LastFlushLatency is how long the last non-empty flush took.
+	LastFlushLatency time.Duration `json:"lastFlushLatency"`
+	// BytesPushed is the total size of the logs accepted by the server.
+	BytesPushed uint64 `json:"bytesPushed"`
+	// PushFailures is the number of batches the server did not accept after
+	// all attempts, including spooled batches pushed again.
+	PushFailures uint64 `json:"pushFailures"`
+	// SpoolFiles and SpoolBytes describe the batches spooled to disk, waiting
+	// to be pushed again.
+	SpoolFiles int   `json:"spoolFiles"`
+	SpoolBytes int64 `json:"spoolBytes"`
+	// Dropped is the number of logs lost for good, by DropReason.
+	Dropped map[string]uint64 `json:"dropped"`
+}
+
+type writerStats struct {
+	committed atomic.Uint64
+
+	mutex             sync.Mutex
+	flushes           uint64
+	lastFlushLatency  time.Duration
+	bytesPushed       uint64
+	pushFailures      uint64
+	dropped           map[string]uint64
+	commitsPerSecond  float64
+	lastRateAt        time.Time
+	lastRateCommitted uint64
+}
+
+func newWriterStats() *writerStats {
+	return &writerStats{
+		dropped:    map[string]uint64{},
+		lastRateAt: time.Now(),
+	}
+}
+
+// updateRate recomputes the commit rate over the time since the last update.
+func (s *writerStats) updateRate() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	now := time.Now()
+	committed := s.committed.Load()
+	if elapsed := now.Sub(s.lastRateAt).Seconds(); elapsed > 0 {
+		s.commitsPerSecond = float64(committed-s.lastRateCommitted) / elapsed
+	}
+	s.lastRateAt = now
+	s.lastRateCommitted = committed
+}
+
+func (s *writerStats) recordFlush(latency time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.flushes++
+	s.lastFlushLatency = latency
+}
+
+func (s *writerStats) recordPush(bytes int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.bytesPushed += uint64(bytes)
+}
+
+func (s *writerStats) recordPushFailure() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.pushFailures++
+}
+
+func (s *writerStats) recordDrop(n int, reason string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.dropped[reason] += uint64(n)
+}
+
+func (w *writer) statsSnapshot() LoggerStats {
+	s := w.stats
+	s.mutex.Lock()
+	stats := LoggerStats{
+		RepoId:           w.config.RepoId,
+		Committed:        s.committed.Load(),
+		CommitsPerSecond: s.commitsPerSecond,
+		Flushes:          s.flushes,
+		LastFlushLatency: s.lastFlushLatency,
+		BytesPushed:      s.bytesPushed,
+		PushFailures:     s.pushFailures,
+		Dropped:          make(map[string]uint64, len(s.dropped)),
+	}
+	for reason, n := range s.dropped {
+		stats.Dropped[reason] = n
+	}
+	s.mutex.Unlock()
+	stats.QueueDepth = w.queue.Len()
+	if files, err := os.ReadDir(w.logsDir); err == nil {
+		for _, file := range files {
+			if info, err := file.Info(); err == nil && !file.IsDir() {
+				stats.SpoolFiles++
+				stats.SpoolBytes += info.Size()
+			}
+		}
+	}
+	return stats
+}
+
+// Stats returns a snapshot of the logger's queue, flush and push statistics.
+func (l *Logger) Stats() LoggerStats {
+	return l.writer.statsSnapshot()
+}
+
+// PublishExpvar publishes the logger's Stats under the given expvar name, so
+// they are served by the expvar handler on /debug/vars. Like expvar.Publish,
+// it panics if the name is already in use.
+func (l *Logger) PublishExpvar(name string) {
+	expvar.Publish(name, expvar.Func(func() any {
+		return l.Stats()
+	}))
+}
@@ -0,0 +1,48 @@
+package logging
+
+import (
+	"encoding/json"
+	"expvar"
+	"testing"
+)
+
+func TestLoggerStats(t *testing.T) {
+	l, _ := newTestLogger(t, &LoggerConfig{MaxQueueSize: 2})
+	trace := l.Trace(&TraceConfig{Id: "trace-1"})
+	trace.SetInput("hello")
+	trace.End()
+
+	stats := l.Stats()
+	if stats.RepoId != "test-repo" || stats.Committed != 2 || stats.QueueDepth != 2 {
+		t.Errorf("unexpected stats before the flush: %+v", stats)
+	}
+	if stats.Dropped[DropReasonQueueFull] != 1 {
+		t.Errorf("expected 1 log dropped on a full queue, got %v", stats.Dropped)
+	}
+
+	l.Flush()
+	stats = l.Stats()
+	if stats.QueueDepth != 0 || stats.Flushes != 1 || stats.BytesPushed == 0 || stats.PushFailures != 0 {
+		t.Errorf("unexpected stats after the flush: %+v", stats)
+	}
+}
+
+func TestLoggerPublishExpvar(t *testing.T) {
+	l, _ := newTestLogger(t, nil)
+	l.Trace(&TraceConfig{Id: "trace-1"})
+	// expvar names cannot be published twice, even with -count.
+	name := "maxim-test-stats-" + NewID()
+	l.PublishExpvar(name)
+
+	v := expvar.Get(name)
+	if v == nil {
+		t.Fatal("stats were not published")
+	}
+	var stats LoggerStats
+	if err := json.Unmarshal([]byte(v.String()), &stats); err != nil {
+		t.Fatal(err)
+	}
+	if stats.RepoId != "test-repo" || stats.Committed != 1 {
+		t.Errorf("unexpected published stats: %+v", stats)
+	}
+}
@@ -0,0 +1,308 @@
+package logging
+
+import (
+	"sync"
+	"time"
+)
+
+// TailSamplingConfig enables tail-based sampling: the commits of a trace are
+// held in memory until the trace ends, and the whole trace is then kept or
+// dropped. A trace is kept when any of the configured rules matches.
+type TailSamplingConfig struct {
+	// KeepErrors keeps traces in which any generation recorded an error.
+	KeepErrors bool
+	// LatencyThreshold keeps traces that took longer. 0 disables the rule.
+	LatencyThreshold time.Duration
+	// Tags keeps traces in which any entity carries one of these tags. An
+	// empty value matches any value of the tag.
+	Tags map[string]string
+	// MaxFeedbackScore keeps traces that received feedback with a score at or
+	// below it.
+	MaxFeedbackScore *int8
+	// Rule is a custom rule evaluated after the built-in ones.
+	Rule func(s *TraceSummary) bool
+
+	// MaxBufferedTraces and MaxBufferedCommits bound the memory held by
+	// traces that have not ended yet. When a limit is hit, the oldest
+	// buffered trace is kept without waiting for its end. They default to
+	// 1000 traces and 100000 commits.
+	MaxBufferedTraces  int
+	MaxBufferedCommits int
+	// TraceTimeout is how long a trace may stay open before it is decided
+	// on what was buffered so far. Defaults to 5 minutes.
+	TraceTimeout time.Duration
+}
+
+// TraceSummary is what tail sampling rules see of a finished trace.
+type TraceSummary struct {
+	TraceId        string
+	Duration       time.Duration
+	HasError       bool
+	Tags           map[string]string
+	FeedbackScores []int8
+	// TimedOut is set when the trace is decided without having ended.
+	TimedOut bool
+}
+
+type tailTrace struct {
+	summary  TraceSummary
+	start    time.Time
+	created  time.Time
+	commits  []*CommitLog
+	members  []string
+	decided  bool
+	keep     bool
+	decideAt time.Time
+}
+
+// tailSampler buffers the commits of open traces. It keeps decided traces
+// around for another TraceTimeout so commits arriving after the trace ended
+// (e.g. a late AddTagToTrace) follow the decision.
+type tailSampler struct {
+	config TailSamplingConfig
+	// now is the clock of the logger, shared with leak detection.
+	now      func() time.Time
+	mutex    sync.Mutex
+	traces   map[string]*tailTrace
+	entities map[string]string
+	buffered int
+	// undecided counts the traces still buffering. pending lists them
+	// oldest first, along with traces decided since they were last
+	// compacted away; decided lists the decided traces by decision time,
+	// until their grace period is over.
+	undecided int
+	pending   []string
+	decided   []string
+}
+
+func newTailSampler(c *TailSamplingConfig, now func() time.Time) *tailSampler {
+	config := *c
+	if config.MaxBufferedTraces <= 0 {
+		config.MaxBufferedTraces = 1000
+	}
+	if config.MaxBufferedCommits <= 0 {
+		config.MaxBufferedCommits = 100000
+	}
+	if config.TraceTimeout <= 0 {
+		config.TraceTimeout = 5 * time.Minute
+	}
+	return &tailSampler{
+		config:   config,
+		now:      now,
+		traces:   map[string]*tailTrace{},
+		entities: map[string]string{},
+	}
+}
+
+// intercept takes ownership of commits that belong to a buffered or decided
+// trace. It returns handled=false for commits it does not track, and the
+// commits released for enqueueing when a trace is kept.
+func (ts *tailSampler) intercept(cl *CommitLog) (released []*CommitLog, handled bool) {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+	traceId, ok := ts.entities[cl.entityID]
+	if !ok {
+		if cl.entity != EntityTrace || cl.action != "create" {
+			return nil, false
+		}
+		traceId = cl.entityID
+		now := ts.now()
+		ts.traces[traceId] = &tailTrace{
+			summary: TraceSummary{TraceId: traceId, Tags: map[string]string{}},
+			start:   timestampFromData(cl.data, "startTimestamp", now),
+			created: now,
+			members: []string{traceId},
+		}
+		ts.entities[traceId] = traceId
+		ts.pending = append(ts.pending, traceId)
+		ts.undecided++
+	}
+	t := ts.traces[traceId]
+	if child := childIdFromCommit(cl); child != "" {
+		ts.entities[child] = traceId
+		t.members = append(t.members, child)
+	}
+	if t.decided {
+		if t.keep {
+			return []*CommitLog{cl}, true
+		}
+		return nil, true
+	}
+	t.commits = append(t.commits, cl)
+	ts.buffered++
+	t.observe(cl)
+	if cl.entity == EntityTrace && cl.entityID == traceId && cl.action == "end" {
+		t.summary.Duration = timestampFromData(cl.data, "endTimestamp", ts.now()).Sub(t.start)
+		released = ts.decide(t, ts.evaluate(&t.summary))
+	}
+	return append(released, ts.evictOverLimit()...), true
+}
+
+// expire decides traces that stayed open past the timeout and forgets
+// decided traces whose grace period is over.
+func (ts *tailSampler) expire(now time.Time) []*CommitLog {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+	var released []*CommitLog
+	remaining := ts.pending[:0]
+	for _, traceId := range ts.pending {
+		t, ok := ts.traces[traceId]
+		if !ok || t.decided {
+			continue
+		}
+		if now.Sub(t.created) >= ts.config.TraceTimeout {
+			t.summary.TimedOut = true
+			t.summary.Duration = now.Sub(t.start)
+			released = append(released, ts.decide(t, ts.evaluate(&t.summary))...)
+			continue
+		}
+		remaining = append(remaining, traceId)
+	}
+	ts.pending = remaining
+	for len(ts.decided) > 0 {
+		traceId := ts.decided[0]
+		t := ts.traces[traceId]
+		if !now.After(t.decideAt.Add(ts.config.TraceTimeout)) {
+			break
+		}
+		for _, id := range t.members {
+			delete(ts.entities, id)
+		}
+		delete(ts.traces, traceId)
+		ts.decided = ts.decided[1:]
+	}
+	return released
+}
+
+// drain decides every open trace on what was buffered so far, e.g. when the
+// logger is cleaned up.
+func (ts *tailSampler) drain() []*CommitLog {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+	var released []*CommitLog
+	now := ts.now()
+	for _, traceId := range ts.pending {
+		if t, ok := ts.traces[traceId]; ok && !t.decided {
+			t.summary.TimedOut = true
+			t.summary.Duration = now.Sub(t.start)
+			released = append(released, ts.decide(t, ts.evaluate(&t.summary))...)
+		}
+	}
+	ts.pending = nil
+	return released
+}
+
+// evictOverLimit keeps the oldest undecided traces until the buffer is back
+// within its limits.
+func (ts *tailSampler) evictOverLimit() []*CommitLog {
+	var released []*CommitLog
+	for len(ts.pending) > 0 && (ts.buffered > ts.config.MaxBufferedCommits || ts.undecided > ts.config.MaxBufferedTraces) {
+		traceId := ts.pending[0]
+		ts.pending = ts.pending[1:]
+		if t, ok := ts.traces[traceId]; ok && !t.decided {
+			released = append(released, ts.decide(t, true)...)
+		}
+	}
+	return released
+}
+
+func (ts *tailSampler) decide(t *tailTrace, keep bool) []*CommitLog {
+	commits := t.commits
+	ts.buffered -= len(commits)
+	t.commits = nil
+	t.decided = true
+	t.keep = keep
+	t.decideAt = ts.now()
+	ts.undecided--
+	ts.decided = append(ts.decided, t.summary.TraceId)
+	if !keep {
+		return nil
+	}
+	return commits
+}
+
+func (ts *tailSampler) evaluate(s *TraceSummary) bool {
+	c := ts.config
+	if c.KeepErrors && s.HasError {
+		return true
+	}
+	if c.LatencyThreshold > 0 && s.Duration > c.LatencyThreshold {
+		return true
+	}
+	for key, want := range c.Tags {
+		if value, ok := s.Tags[key]; ok && (want == "" || want == value) {
+			return true
+		}
+	}
+	if c.MaxFeedbackScore != nil {
+		for _, score := range s.FeedbackScores {
+			if score <= *c.MaxFeedbackScore {
+				return true
+			}
+		}
+	}
+	return c.Rule != nil && c.Rule(s)
+}
+
+// observe folds a commit into the trace summary.
+func (t *tailTrace) observe(cl *CommitLog) {
+	switch cl.action {
+	case "error":
+		t.summary.HasError = true
+	case "add-feedback":
+		if f, ok := cl.data.(*Feedback); ok && f != nil {
+			t.summary.FeedbackScores = append(t.summary.FeedbackScores, f.Score)
+		}
+	case "result":
+		if result, ok := normalizeCommitData(cl.data)["result"].(map[string]interface{}); ok && result["error"] != nil {
+			t.summary.HasError = true
+		}
+	}
+	data, ok := cl.data.(map[string]interface{})
+	if !ok || cl.action == "add-event" {
+		return
+	}
+	if e, ok := data["error"]; ok && e != nil && cl.action != "result" {
+		t.summary.HasError = true
+	}
+	switch tags := data["tags"].(type) {
+	case map[string]string:
+		for key, value := range tags {
+			t.summary.Tags[key] = value
+		}
+	case *map[string]string:
+		if tags != nil {
+			for key, value := range *tags {
+				t.summary.Tags[key] = value
+			}
+		}
+	}
+}
+
+// childIdFromCommit returns the id of the entity a commit adds to its parent.
+func childIdFromCommit(cl *CommitLog) string {
+	switch cl.action {
+	case "add-span", "add-generation", "add-retrieval":
+		if data, ok := cl.data.(map[string]interface{}); ok {
+			id, _ := data["id"].(string)
+			return id
+		}
+	}
+	return ""
+}
+
+func timestampFromData(data interface{}, key string, fallback time.Time) time.Time {
+	m, ok := data.(map[string]interface{})
+	if !ok {
+		return fallback
+	}
+	switch t := m[key].(type) {
+	case time.Time:
+		return t
+	case *time.Time:
+		if t != nil {
+			return *t
+		}
+	}
+	return fallback
+}
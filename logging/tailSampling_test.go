@@ -0,0 +1,106 @@
+package logging
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTailSamplingKeepsErroredAndSlowTraces(t *testing.T) {
+	l, ts := newTestLogger(t, &LoggerConfig{
+		TailSampling: &TailSamplingConfig{
+			KeepErrors:       true,
+			LatencyThreshold: time.Minute,
+			Tags:             map[string]string{"keep": ""},
+		},
+	})
+	fast := l.Trace(&TraceConfig{Id: "fast"})
+	fast.AddSpan(&SpanConfig{Id: "fast-span"}).End()
+	fast.End()
+
+	errored := l.Trace(&TraceConfig{Id: "errored"})
+	generation := errored.AddSpan(&SpanConfig{Id: "errored-span"}).AddGeneration(&GenerationConfig{Id: "errored-generation"})
+	generation.SetError(&GenerationError{Message: "rate limited"})
+	errored.End()
+	l.AddTagToSpan("errored-span", "late", "tag")
+
+	start := time.Now().Add(-2 * time.Minute)
+	slow := l.Trace(&TraceConfig{Id: "slow", StartTimestamp: &start})
+	slow.End()
+
+	tagged := l.Trace(&TraceConfig{Id: "tagged"})
+	tagged.AddTag("keep", "yes")
+	tagged.End()
+
+	open := l.Trace(&TraceConfig{Id: "open"})
+	open.SetInput("still running")
+	l.Flush()
+
+	logs := ts.logs()
+	if strings.Contains(logs, "fast") {
+		t.Errorf("fast trace was kept\n%s", logs)
+	}
+	if strings.Contains(logs, "trace{id=open") {
+		t.Errorf("open trace was released before it ended\n%s", logs)
+	}
+	for _, want := range []string{
+		"trace{id=errored,action=create",
+		"span{id=errored-span,action=add-generation",
+		"generation{id=errored-generation,action=update",
+		"span{id=errored-span,action=update",
+		"trace{id=slow,action=end",
+		"trace{id=tagged,action=end",
+	} {
+		if !strings.Contains(logs, want) {
+			t.Errorf("pushed logs missing %q\n%s", want, logs)
+		}
+	}
+}
+
+func TestTailSamplingBoundsBufferedTraces(t *testing.T) {
+	l, ts := newTestLogger(t, &LoggerConfig{
+		TailSampling: &TailSamplingConfig{MaxBufferedTraces: 1, TraceTimeout: time.Hour},
+	})
+	l.Trace(&TraceConfig{Id: "first"})
+	l.Trace(&TraceConfig{Id: "second"})
+	l.Flush()
+
+	logs := ts.logs()
+	if !strings.Contains(logs, "trace{id=first,action=create") || strings.Contains(logs, "second") {
+		t.Errorf("expected only the oldest trace to be released\n%s", logs)
+	}
+	if got := l.writer.tail.expire(time.Now().Add(2 * time.Hour)); len(got) != 0 {
+		t.Errorf("timed out trace without matching rules was kept: %d commits", len(got))
+	}
+	// Both traces are decided, and their grace period is over as well.
+	tail := l.writer.tail
+	if tail.undecided != 0 || len(tail.pending) != 0 || len(tail.decided) != 0 || len(tail.traces) != 0 || len(tail.entities) != 0 {
+		t.Errorf("decided traces were not forgotten after their grace period: %d undecided, %d left", tail.undecided, len(tail.traces))
+	}
+}
+
+func TestTailSamplingUsesTheLoggerClock(t *testing.T) {
+	var mutex sync.Mutex
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	l, ts := newTestLogger(t, &LoggerConfig{
+		Clock: ClockFunc(func() time.Time {
+			mutex.Lock()
+			defer mutex.Unlock()
+			return now
+		}),
+		TailSampling: &TailSamplingConfig{TraceTimeout: time.Minute, Tags: map[string]string{"tier": "gold"}},
+	})
+	l.Trace(&TraceConfig{Id: "trace-1", Tags: &map[string]string{"tier": "gold"}})
+	l.Flush()
+	if strings.Contains(ts.logs(), "trace-1") {
+		t.Fatalf("open trace was released before its timeout\n%s", ts.logs())
+	}
+	mutex.Lock()
+	now = now.Add(2 * time.Minute)
+	mutex.Unlock()
+	l.Flush()
+	if !strings.Contains(ts.logs(), "trace{id=trace-1,action=create") {
+		t.Errorf("trace was not decided once the logger clock passed its timeout\n%s", ts.logs())
+	}
+}
@@ -1,11 +1,16 @@
 package logging
 
+import "time"
+
 type TraceConfig struct {
-	Id        string             `json:"id"`
-	SpanId    *string            `json:"spanId,omitempty"`
-	Name      *string            `json:"name,omitempty"`
-	Tags      *map[string]string `json:"tags,omitempty"`
-	SessionId *string
+	Id             string                 `json:"id"`
+	SpanId         *string                `json:"spanId,omitempty"`
+	Name           *string                `json:"name,omitempty"`
+	Tags           *map[string]string     `json:"tags,omitempty"`
+	StartTimestamp *time.Time             `json:"startTimestamp,omitempty"`
+	EndTimestamp   *time.Time             `json:"endTimestamp,omitempty"`
+	Metadata       map[string]interface{} `json:"metadata,omitempty"`
+	SessionId      *string
 }
 
 type Trace struct {
@@ -14,17 +19,30 @@ type Trace struct {
 }
 
 func newTrace(c *TraceConfig, w *writer) *Trace {
+	w.assignId(EntityTrace, &c.Id)
 	t := &Trace{
 		eventEmitter: &eventEmitter{
 			base: newBase(EntityTrace, c.Id, &baseConfig{
-				Id:     c.Id,
-				SpanId: c.SpanId,
-				Name:   c.Name,
-				Tags:   c.Tags,
+				Id:             c.Id,
+				SpanId:         c.SpanId,
+				Name:           c.Name,
+				Tags:           c.Tags,
+				StartTimestamp: c.StartTimestamp,
+				EndTimestamp:   c.EndTimestamp,
+				Metadata:       c.Metadata,
 			}, w),
 		},
 		SessionId: c.SessionId,
 	}
+	// The Sampler decides before the trace is registered anywhere, so a
+	// dropped trace leaves nothing behind once it ends.
+	unsampledTrace := ""
+	if w.config.Sampler != nil && !w.config.Sampler.ShouldSample(c) {
+		unsampledTrace = c.Id
+	}
+	if t.skipIfUnsampled(unsampledTrace) {
+		return t
+	}
 	tData := t.data()
 	tData["id"] = c.Id
 	t.commit("create", tData)
@@ -33,18 +51,31 @@ func newTrace(c *TraceConfig, w *writer) *Trace {
 
 func (t *Trace) AddGeneration(c *GenerationConfig) *Generation {
 	g := newGeneration(c, t.writer)
+	if g.skipIfUnsampled(t.unsampledTrace) {
+		return g
+	}
+	t.addChild(g.base)
 	gData := g.data()
 	gData["id"] = c.Id
 	t.commit("add-generation", gData)
 	return g
 }
 
+// Sampled reports whether the trace was kept by the logger's Sampler.
+func (t *Trace) Sampled() bool {
+	return t.unsampledTrace == ""
+}
+
 func (t *Trace) SetFeedback(f *Feedback) {
 	t.commit("add-feedback", f)
 }
 
 func (t *Trace) AddSpan(c *SpanConfig) *Span {
 	s := newSpan(c, t.writer)
+	if s.skipIfUnsampled(t.unsampledTrace) {
+		return s
+	}
+	t.addChild(s.base)
 	sData := s.data()
 	sData["id"] = c.Id
 	t.commit("add-span", sData)
@@ -53,26 +84,39 @@ func (t *Trace) AddSpan(c *SpanConfig) *Span {
 
 func (t *Trace) AddRetrieval(c *RetrievalConfig) *Retrieval {
 	r := newRetrieval(c, t.writer)
+	if r.skipIfUnsampled(t.unsampledTrace) {
+		return r
+	}
+	t.addChild(r.base)
 	rData := r.data()
 	rData["id"] = c.Id
 	t.commit("add-retrieval", rData)
 	return r
 }
 
-func (t *Trace) SetInput(i string) *Trace {
+// SetInput sets the input of the trace: a string, or any JSON serializable
+// value such as a struct or a json.RawMessage.
+func (t *Trace) SetInput(i interface{}) *Trace {
 	t.commit("update", map[string]interface{}{
 		"input": i,
 	})
 	return t
 }
 
-func (t *Trace) SetOutput(o string) *Trace {
+// SetOutput sets the output of the trace, like SetInput.
+func (t *Trace) SetOutput(o interface{}) *Trace {
 	t.commit("update", map[string]interface{}{
 		"output": o,
 	})
 	return t
 }
 
+// SetError records the error on the trace and marks it as failed.
+func (t *Trace) SetError(err error) *Trace {
+	t.recordError(err)
+	return t
+}
+
 func (t *Trace) data() map[string]interface{} {
 	bData := t.base.data()
 	if t.SessionId != nil {
@@ -1,14 +1,34 @@
 package logging
 
 import (
+	"fmt"
 	"time"
+	"unicode"
+
+	"github.com/maximhq/maxim-go/internal"
 )
 
+// MaxIdLength is the maximum length of an entity id.
+const MaxIdLength = 128
+
 func utcNow() time.Time {
 	return time.Now().UTC()
 }
 
-func utcNowPtr() *time.Time {
-	now := time.Now().UTC()
-	return &now
+// NewID returns a new entity id: a time-ordered (version 7) UUID.
+func NewID() string {
+	return internal.NewUUIDv7()
+}
+
+// validateId checks a caller-supplied entity id.
+func validateId(id string) error {
+	if len(id) > MaxIdLength {
+		return fmt.Errorf("%w: %q is longer than %d bytes", ErrInvalidId, id, MaxIdLength)
+	}
+	for _, r := range id {
+		if unicode.IsSpace(r) || unicode.IsControl(r) {
+			return fmt.Errorf("%w: %q contains whitespace or control characters", ErrInvalidId, id)
+		}
+	}
+	return nil
 }
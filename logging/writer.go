@@ -1,9 +1,14 @@
 package logging
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"log/slog"
+	"net/http"
 	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/maximhq/maxim-go/apis"
@@ -14,10 +19,28 @@ import (
 type writerConfig struct {
 	BaseUrl              string
 	ApiKey               string
+	HTTPClient           *http.Client
 	RepoId               string
 	AutoFlush            bool
 	FlushIntervalSeconds int
 	IsDebug              bool
+	Sinks                []Sink
+	Sampler              Sampler
+	TailSampling         *TailSamplingConfig
+	Redaction            *RedactionConfig
+	Limits               *PayloadLimits
+	DefaultTags          map[string]string
+	PausePushes          bool
+	EndedPolicy          EndedPolicy
+	LeakDetection        *LeakDetectionConfig
+	CascadeEnd           bool
+	RecordErrorStacks    bool
+	Clock                Clock
+	Processors           []Processor
+	Logger               *slog.Logger
+	MaxQueueSize         int
+	OnError              func(error)
+	OnDrop               func(n int, reason string)
 }
 
 type writer struct {
@@ -27,21 +50,66 @@ type writer struct {
 	ticker  *time.Ticker
 	isDebug bool
 	logsDir string
-	logger  *log.Logger
+	logger  *slog.Logger
+
+	stats      *writerStats
+	tail       *tailSampler
+	leaks      *leakDetector
+	processors []Processor
+	// paused makes flushes spool logs to disk instead of pushing them.
+	paused atomic.Bool
+	// discarding makes flushes drop logs.
+	discarding atomic.Bool
+	// unsampled maps the ids of entities dropped by the Sampler to their
+	// trace, so commits made by id through the Logger are dropped as well.
+	// unsampledTraces lists the ids of each dropped trace, all forgotten
+	// when the trace ends.
+	unsampledMutex  sync.Mutex
+	unsampled       map[string]string
+	unsampledTraces map[string][]string
 }
 
+// pushAttempts is the number of times a batch is pushed before it is spooled
+// to disk.
+const pushAttempts = 3
+
 // NewWriter creates a new Writer instance
 func newWriter(c *writerConfig) *writer {
 	w := &writer{
 		ticker:  time.NewTicker(time.Duration(c.FlushIntervalSeconds) * time.Second),
 		config:  c,
-		logsDir: fmt.Sprintf("%smaxim-sdk/%s/maxim-logs", os.TempDir(), c.RepoId),
+		logsDir: filepath.Join(os.TempDir(), "maxim-sdk", c.RepoId, "maxim-logs"),
 		queue:   utils.NewQueue[*CommitLog](),
 		mutex:   utils.NewMutex(),
 		isDebug: c.IsDebug,
+		logger:  c.Logger,
+
+		stats:           newWriterStats(),
+		unsampled:       map[string]string{},
+		unsampledTraces: map[string][]string{},
+	}
+	if w.logger == nil {
+		w.logger = internal.NewLogger(c.IsDebug)
+	}
+	w.logger = w.logger.With("repoId", c.RepoId)
+	w.paused.Store(c.PausePushes)
+	if c.LeakDetection != nil {
+		w.leaks = newLeakDetector(c.LeakDetection, c.IsDebug)
+	}
+	if c.TailSampling != nil {
+		w.tail = newTailSampler(c.TailSampling, w.now)
 	}
-	if w.isDebug {
-		w.logger = internal.NewDebugLogger()
+	if c.Redaction != nil {
+		w.processors = append(w.processors, newRedactor(c.Redaction))
+	}
+	if c.Limits != nil {
+		w.processors = append(w.processors, newPayloadLimiter(c.Limits))
+	}
+	w.processors = append(w.processors, c.Processors...)
+	for _, sink := range c.Sinks {
+		if s, ok := sink.(loggerSink); ok {
+			s.attachLogger(w.logger)
+		}
 	}
 	w.init()
 	return w
@@ -58,21 +126,19 @@ func (w *writer) init() {
 	}()
 }
 
-func (w *writer) writeToFile(logs []*CommitLog) error {
+// writeToFile spools a serialized batch to its own file in the logs
+// directory, to be pushed again on the next flush.
+func (w *writer) writeToFile(content string) error {
 	if _, err := os.Stat(w.logsDir); os.IsNotExist(err) {
 		err := os.MkdirAll(w.logsDir, 0755)
 		if err != nil {
-			return fmt.Errorf("failed to create logs directory: %w", err)
+			return fmt.Errorf("%w: failed to create logs directory: %v", ErrSpoolFailed, err)
 		}
 	}
-	content := ""
-	for _, log := range logs {
-		content += log.Serialize() + "\n"
-	}
-	fileName := fmt.Sprintf("%s/%s.log", w.logsDir, time.Now().Format("2006-01-02"))
+	fileName := filepath.Join(w.logsDir, time.Now().UTC().Format("2006-01-02T15-04-05.000000000")+".log")
 	err := os.WriteFile(fileName, []byte(content), 0644)
 	if err != nil {
-		return fmt.Errorf("failed to write logs to file: %w", err)
+		return fmt.Errorf("%w: failed to write logs to file: %v", ErrSpoolFailed, err)
 	}
 	return nil
 }
@@ -89,92 +155,304 @@ func (w *writer) flushLogFiles() error {
 		if file.IsDir() {
 			continue
 		}
-		filePath := fmt.Sprintf("%s/%s", w.logsDir, file.Name())
+		filePath := filepath.Join(w.logsDir, file.Name())
 		content, err := os.ReadFile(filePath)
 		if err != nil {
 			continue
 		}
-		resp := apis.PushLogs(w.config.BaseUrl, w.config.ApiKey, w.config.RepoId, string(content))
+		resp := apis.PushLogsWithClient(w.config.HTTPClient, w.config.BaseUrl, w.config.ApiKey, w.config.RepoId, string(content))
 		if resp.Error != nil {
+			w.logger.Warn("failed to push spooled logs", "file", filePath, "error", resp.Error.Message)
+			w.stats.recordPushFailure()
+			w.reportError(fmt.Errorf("%w: spooled batch %s: %s", ErrPushFailed, file.Name(), resp.Error.Message))
 			continue
 		}
+		w.stats.recordPush(len(content))
 		os.Remove(filePath)
 	}
 	return nil
 }
 
 func (w *writer) flushLogs(logs []*CommitLog) error {
+	if w.discarding.Load() {
+		w.logger.Debug("pushes discarded, dropping logs", "batchSize", len(logs))
+		w.reportDrop(len(logs), DropReasonDiscarded)
+		return nil
+	}
 	_, err := os.Stat(w.logsDir)
 	if err != nil && !os.IsNotExist(err) {
 		return fmt.Errorf("failed to check logs directory: %w", err)
 	}
-	err = w.flushLogFiles()
-	if err != nil {
-		log.Println("[MaximSDK][Error]: error while flushing log files %w", err.Error())
-	}
-	if w.logger != nil {
-		w.logger.Println("flushing logs to server")
-		w.logger.Println("[===========[LOG START]==============]")
+	paused := w.paused.Load()
+	if !paused {
+		err = w.flushLogFiles()
+		if err != nil {
+			w.logger.Error("failed to flush spooled log files", "error", err)
+		}
 	}
+	debug := w.logger.Enabled(context.Background(), slog.LevelDebug)
 	content := ""
+	batchSize := 0
 	for _, log := range logs {
-		if w.logger != nil {
-			w.logger.Println(log.Serialize())
+		serialized, err := log.serialize()
+		if err != nil {
+			w.logger.Error("dropping log that cannot be serialized", "error", err)
+			w.reportError(err)
+			w.reportDrop(1, DropReasonSerializationFailed)
+			continue
+		}
+		if debug {
+			w.logger.Debug("pushing log", "log", serialized)
 		}
-		content += log.Serialize() + "\n"
+		content += serialized + "\n"
+		batchSize++
 	}
-	if w.logger != nil {
-		w.logger.Println("[===========[LOG END]==============]")
+	if batchSize == 0 {
+		return nil
 	}
-	resp := apis.PushLogs(w.config.BaseUrl, w.config.ApiKey, w.config.RepoId, content)
-	if resp.Error != nil {
-		log.Println("[MaximSDK][Error]: failed to push logs to server: %w", resp.Error.Message)
-		err := w.writeToFile(logs)
-		if err != nil {
-			log.Println("[MaximSDK][Error]: failed to backup logs to file: %w", err)
+	if paused {
+		if err := w.writeToFile(content); err != nil {
+			w.logger.Error("failed to spool logs to disk", "batchSize", batchSize, "error", err)
+			w.reportError(err)
+			w.reportDrop(batchSize, DropReasonSpoolFailed)
+			return err
 		}
-		return fmt.Errorf("%s", resp.Error.Message)
+		w.logger.Debug("pushes paused, logs spooled to disk", "batchSize", batchSize)
+		return nil
 	}
-	if w.logger != nil {
-		w.logger.Println("logs pushed to server successfully")
+	err = w.push(content, batchSize)
+	if err != nil {
+		w.reportError(err)
+		if err := w.writeToFile(content); err != nil {
+			w.logger.Error("failed to spool logs to disk", "batchSize", batchSize, "error", err)
+			w.reportError(err)
+			w.reportDrop(batchSize, DropReasonSpoolFailed)
+		}
+		return err
 	}
+	w.logger.Debug("logs pushed to server", "batchSize", len(logs))
 	return nil
 }
 
+// push sends a batch of serialized logs, retrying with exponential backoff.
+func (w *writer) push(content string, batchSize int) error {
+	retryDelay := 100 * time.Millisecond
+	var lastError string
+	for attempt := 1; attempt <= pushAttempts; attempt++ {
+		resp := apis.PushLogsWithClient(w.config.HTTPClient, w.config.BaseUrl, w.config.ApiKey, w.config.RepoId, content)
+		if resp.Error == nil {
+			w.stats.recordPush(len(content))
+			return nil
+		}
+		lastError = resp.Error.Message
+		w.logger.Warn("failed to push logs", "batchSize", batchSize, "attempt", attempt, "error", lastError)
+		if attempt < pushAttempts {
+			time.Sleep(retryDelay)
+			retryDelay *= 2
+		}
+	}
+	w.stats.recordPushFailure()
+	return fmt.Errorf("%w after %d attempts: %s", ErrPushFailed, pushAttempts, lastError)
+}
+
 func (w *writer) flush() {
 	err := w.mutex.Acquire()
 	if err != nil {
+		w.logger.Warn("skipping flush, another flush is in progress", "error", err)
 		return
 	}
 	defer w.mutex.Release()
-	if w.logger != nil {
-		w.logger.Println("flushing logs")
+	if w.leaks != nil {
+		w.leaks.check(w, w.now(), false)
 	}
+	if w.tail != nil {
+		w.enqueueAll(w.tail.expire(w.now()))
+	}
+	w.stats.updateRate()
 	logs := w.queue.DequeueAll()
 	if len(logs) == 0 {
-		if w.logger != nil {
-			w.logger.Println("no logs to flush")
-		}
+		w.logger.Debug("no logs to flush")
 		return
 	}
+	w.logger.Debug("flushing logs", "batchSize", len(logs))
+	start := time.Now()
+	w.exportToSinks(logs)
 	err = w.flushLogs(logs)
+	w.stats.recordFlush(time.Since(start))
 	if err != nil {
-		log.Println("[MaximSDK][Error]: error while flushing logs: ", err.Error())
+		w.logger.Error("failed to flush logs, spooled to disk", "batchSize", len(logs), "error", err)
+		return
+	}
+	w.logger.Debug("logs flushed", "batchSize", len(logs))
+}
+
+// resume re-enables pushes and pushes the logs spooled while they were
+// paused. If a flush is in progress, they are pushed by the next one.
+func (w *writer) resume() {
+	w.paused.Store(false)
+	if err := w.mutex.Acquire(); err != nil {
+		return
+	}
+	defer w.mutex.Release()
+	if err := w.flushLogFiles(); err != nil {
+		w.logger.Error("failed to flush spooled log files", "error", err)
+	}
+}
+
+func (w *writer) exportToSinks(logs []*CommitLog) {
+	for _, sink := range w.config.Sinks {
+		if err := sink.Export(logs); err != nil {
+			w.logger.Error("failed to export logs to sink", "batchSize", len(logs), "error", err)
+		}
+	}
+}
+
+// assignId generates the id of an entity created without one, and checks
+// the ids supplied by callers.
+func (w *writer) assignId(entity Entity, id *string) {
+	if *id == "" {
+		*id = NewID()
 		return
 	}
-	if w.logger != nil {
-		w.logger.Println("logs flushed successfully")
+	if err := validateId(*id); err != nil {
+		w.logger.Warn("invalid entity id", "entity", entity, "error", err)
+		w.reportError(err)
+	}
+}
+
+// now returns the current time of the logger's Clock, in UTC.
+func (w *writer) now() time.Time {
+	if w.config.Clock != nil {
+		return w.config.Clock.Now().UTC()
 	}
+	return utcNow()
+}
+
+func (w *writer) markUnsampled(id, traceId string) {
+	w.unsampledMutex.Lock()
+	defer w.unsampledMutex.Unlock()
+	w.unsampled[id] = traceId
+	w.unsampledTraces[traceId] = append(w.unsampledTraces[traceId], id)
+}
+
+// unsampledTrace returns the dropped trace the entity belongs to, or "" if
+// it was sampled.
+func (w *writer) unsampledTrace(id string) string {
+	w.unsampledMutex.Lock()
+	defer w.unsampledMutex.Unlock()
+	return w.unsampled[id]
+}
+
+// forgetUnsampled forgets an ended unsampled entity, and every entity of its
+// trace when it is the trace, ended or not.
+func (w *writer) forgetUnsampled(id string) {
+	w.unsampledMutex.Lock()
+	defer w.unsampledMutex.Unlock()
+	traceId, ok := w.unsampled[id]
+	if !ok {
+		return
+	}
+	if traceId != id {
+		delete(w.unsampled, id)
+		return
+	}
+	for _, member := range w.unsampledTraces[traceId] {
+		delete(w.unsampled, member)
+	}
+	delete(w.unsampledTraces, traceId)
 }
 
 func (w *writer) commit(cl *CommitLog) {
-	if w.logger != nil {
-		w.logger.Println("Committing log: ", cl.Serialize())
+	if cl.action == "end" && w.leaks != nil {
+		w.leaks.untrack(cl.entity, cl.entityID)
+	}
+	if w.unsampledTrace(cl.entityID) != "" {
+		if cl.action == "end" {
+			w.forgetUnsampled(cl.entityID)
+		}
+		return
+	}
+	w.process(cl, 0)
+}
+
+// process runs the commit through the processors from the given one on,
+// then hands it to the tail sampler or the queue, followed by the commits
+// the processors added after it.
+func (w *writer) process(cl *CommitLog, from int) {
+	type followUp struct {
+		cl   *CommitLog
+		from int
+	}
+	var followUps []followUp
+	for i := from; i < len(w.processors); i++ {
+		processed, keep := w.processors[i].Process(cl)
+		if !keep || processed == nil {
+			w.logger.Debug("log vetoed by processor", "entity", cl.entity, "entityId", cl.entityID, "action", cl.action)
+			return
+		}
+		for _, then := range processed.then {
+			followUps = append(followUps, followUp{cl: then, from: i + 1})
+		}
+		processed.then = nil
+		cl = processed
+	}
+	if w.tail != nil {
+		if released, handled := w.tail.intercept(cl); handled {
+			w.enqueueAll(released)
+		} else {
+			w.enqueue(cl)
+		}
+	} else {
+		w.enqueue(cl)
+	}
+	for _, f := range followUps {
+		w.process(f.cl, f.from)
+	}
+}
+
+func (w *writer) enqueueAll(logs []*CommitLog) {
+	for _, cl := range logs {
+		w.enqueue(cl)
+	}
+}
+
+func (w *writer) enqueue(cl *CommitLog) {
+	if w.logger.Enabled(context.Background(), slog.LevelDebug) {
+		w.logger.Debug("committing log", "log", cl.Serialize())
+	}
+	if !w.queue.EnqueueIfBelow(cl, w.config.MaxQueueSize) {
+		w.logger.Warn("dropping log, queue is full", "maxQueueSize", w.config.MaxQueueSize)
+		w.reportDrop(1, DropReasonQueueFull)
+		return
+	}
+	w.stats.committed.Add(1)
+}
+
+func (w *writer) reportError(err error) {
+	if w.config.OnError != nil {
+		w.config.OnError(err)
+	}
+}
+
+func (w *writer) reportDrop(n int, reason string) {
+	w.stats.recordDrop(n, reason)
+	if w.config.OnDrop != nil {
+		w.config.OnDrop(n, reason)
 	}
-	w.queue.Enqueue(cl)
 }
 
 func (w *writer) cleanup() {
+	if w.leaks != nil {
+		w.leaks.check(w, w.now(), true)
+	}
+	if w.tail != nil {
+		w.enqueueAll(w.tail.drain())
+	}
 	w.flush()
 	w.ticker.Stop()
+	for _, sink := range w.config.Sinks {
+		if err := sink.Shutdown(); err != nil {
+			w.logger.Error("failed to shut down sink", "error", err)
+		}
+	}
 }
@@ -2,7 +2,10 @@ package maxim
 
 import (
 	"fmt"
+	"log/slog"
+	"net/http"
 	"sync"
+	"time"
 
 	"github.com/maximhq/maxim-go/apis"
 	"github.com/maximhq/maxim-go/logging"
@@ -12,45 +15,235 @@ type MaximSDKConfig struct {
 	BaseUrl *string
 	ApiKey  string
 	Debug   bool
+	// Logger receives the SDK's own diagnostics and is handed to every
+	// logger created through GetLogger that does not set its own.
+	Logger *slog.Logger
+	// HTTPClient is used for every request to the Maxim API, including log
+	// pushes of loggers that do not set their own.
+	HTTPClient *http.Client
+	// DefaultTags are added to every entity of every logger created through
+	// GetLogger. LoggerConfig.DefaultTags override them.
+	DefaultTags map[string]string
+	// LazyRepoCheck creates loggers even when their log repository cannot be
+	// verified because the Maxim API is unreachable, failing or rate
+	// limiting, e.g. during an outage. Their logs are spooled to disk while
+	// the check is retried in the background, and pushed once it succeeds.
+	// A repository the API rejects is still an error of GetLogger. When a
+	// background check is rejected, the logger is removed and its logs are
+	// dropped, reported to LoggerConfig.OnDrop. Failed checks are reported
+	// to LoggerConfig.OnError.
+	LazyRepoCheck bool
+	// RepoCheckRetryInterval is the first delay between background checks,
+	// doubled after each failure up to a minute. Defaults to 5 seconds.
+	RepoCheckRetryInterval time.Duration
 }
 
 type Maxim struct {
 	baseUrl string
 	apiKey  string
 	debug   bool
+	logger  *slog.Logger
+	tags    map[string]string
+	client  *http.Client
+	// mutex guards loggers, verified and checks.
+	mutex   sync.RWMutex
 	loggers map[string]*logging.Logger
+	// verified holds the log repositories known to exist, so they are
+	// checked over the network only once.
+	verified map[string]bool
+	// checks holds the repository checks in flight.
+	checks map[string]*repoCheck
+
+	lazy          bool
+	retryInterval time.Duration
+	// done stops background repository checks on Cleanup.
+	done      chan struct{}
+	closeDone sync.Once
+
+	// Logger defaults set through New.
+	repoId        string
+	flushInterval *int
+	autoFlush     *bool
 }
 
+// Init creates the SDK from a config without validating it. Prefer New,
+// which also reads the environment and returns configuration errors.
 func Init(c *MaximSDKConfig) *Maxim {
-	baseUrl := "https://app.getmaxim.ai"
+	baseUrl := defaultBaseUrl
 	if c.BaseUrl != nil {
 		baseUrl = *c.BaseUrl
 	}
 	return &Maxim{
-		baseUrl: baseUrl,
-		apiKey:  c.ApiKey,
-		debug:   c.Debug,
-		loggers: map[string]*logging.Logger{},
+		baseUrl:  baseUrl,
+		apiKey:   c.ApiKey,
+		debug:    c.Debug,
+		logger:   c.Logger,
+		tags:     c.DefaultTags,
+		client:   c.HTTPClient,
+		loggers:  map[string]*logging.Logger{},
+		verified: map[string]bool{},
+		checks:   map[string]*repoCheck{},
+
+		lazy:          c.LazyRepoCheck,
+		retryInterval: c.RepoCheckRetryInterval,
+		done:          make(chan struct{}),
 	}
 }
 
+// GetLogger returns the logger of a log repository, creating it on first
+// use. The config may be nil, or leave Id empty, when the SDK was created by
+// New with a log repository. The config is copied, never modified.
 func (m *Maxim) GetLogger(c *logging.LoggerConfig) (*logging.Logger, error) {
-	resp := apis.DoesLogRepoExists(m.baseUrl, m.apiKey, c.Id)
-	if resp.Error != nil {
-		return nil, fmt.Errorf("Repo not found %s", resp.Error.Message)
+	var config logging.LoggerConfig
+	if c != nil {
+		config = *c
+	}
+	if config.Id == "" {
+		config.Id = m.repoId
+	}
+	if config.Id == "" {
+		return nil, fmt.Errorf("%w: log repository id is required, set %s or LoggerConfig.Id", ErrInvalidConfig, EnvLogRepoId)
+	}
+	if config.FlushIntervalSeconds != nil && *config.FlushIntervalSeconds <= 0 {
+		return nil, fmt.Errorf("%w: flush interval must be positive, got %d", ErrInvalidConfig, *config.FlushIntervalSeconds)
+	}
+	m.mutex.RLock()
+	l, ok := m.loggers[config.Id]
+	m.mutex.RUnlock()
+	if ok {
+		return l, nil
+	}
+	repoErr := m.checkRepo(config.Id)
+	if repoErr != nil && (!m.lazy || !isTransient(repoErr)) {
+		return nil, repoErr
+	}
+	if repoErr != nil {
+		config.PausePushes = true
 	}
-	if _, ok := m.loggers[c.Id]; !ok {
-		// Overrides isDebug value from config
-		c.IsDebug = m.debug
-		m.loggers[c.Id] = logging.NewLogger(m.baseUrl, m.apiKey, c)
+	// Overrides isDebug value from config
+	config.IsDebug = m.debug
+	if config.Logger == nil {
+		config.Logger = m.logger
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = m.client
+	}
+	if config.FlushIntervalSeconds == nil {
+		config.FlushIntervalSeconds = m.flushInterval
+	}
+	if config.AutoFlush == nil {
+		config.AutoFlush = m.autoFlush
+	}
+	if len(m.tags) > 0 {
+		tags := make(map[string]string, len(m.tags)+len(config.DefaultTags))
+		for key, value := range m.tags {
+			tags[key] = value
+		}
+		for key, value := range config.DefaultTags {
+			tags[key] = value
+		}
+		config.DefaultTags = tags
+	}
+	m.mutex.Lock()
+	// Another call may have created the logger while the repository was
+	// being checked.
+	if l, ok := m.loggers[config.Id]; ok {
+		m.mutex.Unlock()
+		return l, nil
+	}
+	l = logging.NewLogger(m.baseUrl, m.apiKey, &config)
+	m.loggers[config.Id] = l
+	m.mutex.Unlock()
+	if repoErr != nil {
+		m.reportRepoError(config.OnError, repoErr)
+		go m.verifyInBackground(l, config.Id, config.OnError)
+	}
+	return l, nil
+}
+
+// RemoveLogger removes the logger of a log repository from the SDK, then
+// flushes and closes it. It reports whether the logger existed.
+func (m *Maxim) RemoveLogger(id string) bool {
+	return m.removeLogger(id, nil)
+}
+
+// removeLogger is RemoveLogger, only removing the logger if it is l when l
+// is set.
+func (m *Maxim) removeLogger(id string, l *logging.Logger) bool {
+	m.mutex.Lock()
+	current, ok := m.loggers[id]
+	if ok && (l == nil || current == l) {
+		delete(m.loggers, id)
+	} else {
+		ok = false
+	}
+	m.mutex.Unlock()
+	if ok {
+		current.Cleanup()
+	}
+	return ok
+}
+
+// Loggers returns the loggers created so far, by log repository id.
+func (m *Maxim) Loggers() map[string]*logging.Logger {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	loggers := make(map[string]*logging.Logger, len(m.loggers))
+	for id, l := range m.loggers {
+		loggers[id] = l
+	}
+	return loggers
+}
+
+// repoCheck is a repository check in flight, shared by the calls waiting
+// for it.
+type repoCheck struct {
+	done chan struct{}
+	err  error
+}
+
+// checkRepo verifies that the log repository exists, once per repository.
+// The request is sent without holding the mutex, and concurrent calls for
+// the same repository share it.
+func (m *Maxim) checkRepo(repoId string) error {
+	m.mutex.Lock()
+	if m.verified[repoId] {
+		m.mutex.Unlock()
+		return nil
+	}
+	if check, ok := m.checks[repoId]; ok {
+		m.mutex.Unlock()
+		<-check.done
+		return check.err
+	}
+	check := &repoCheck{done: make(chan struct{})}
+	m.checks[repoId] = check
+	m.mutex.Unlock()
+
+	check.err = m.verifyRepo(repoId)
+	m.mutex.Lock()
+	delete(m.checks, repoId)
+	if check.err == nil {
+		m.verified[repoId] = true
+	}
+	m.mutex.Unlock()
+	close(check.done)
+	return check.err
+}
+
+func (m *Maxim) verifyRepo(repoId string) error {
+	resp := apis.DoesLogRepoExistsWithClient(m.client, m.baseUrl, m.apiKey, repoId)
+	if resp.Error != nil {
+		return &repoError{repoId: repoId, message: resp.Error.Message, status: resp.StatusCode}
 	}
-	return m.loggers[c.Id], nil
+	return nil
 }
 
 func (m *Maxim) Cleanup() {
-	if m.loggers != nil {
+	m.closeDone.Do(func() { close(m.done) })
+	if loggers := m.Loggers(); len(loggers) > 0 {
 		var wg sync.WaitGroup
-		for _, l := range m.loggers {
+		for _, l := range loggers {
 			wg.Add(1)
 			go func(logger *logging.Logger) {
 				defer wg.Done()
@@ -1,9 +1,7 @@
 package maxim_test
 
 import (
-	"crypto/rand"
 	"encoding/json"
-	"fmt"
 	"os"
 	"testing"
 	"time"
@@ -12,19 +10,6 @@ import (
 	"github.com/maximhq/maxim-go/logging"
 )
 
-func uuid() string {
-	uuid := make([]byte, 16)
-	_, err := rand.Read(uuid)
-	if err != nil {
-		return ""
-	}
-	// Set version (4) and variant (2)
-	uuid[6] = (uuid[6] & 0x0f) | 0x40
-	uuid[8] = (uuid[8] & 0x3f) | 0x80
-
-	return fmt.Sprintf("%x-%x-%x-%x-%x", uuid[0:4], uuid[4:6], uuid[6:8], uuid[8:10], uuid[10:])
-}
-
 type TestConfig struct {
 	BaseUrl string `json:"baseUrl"`
 	RepoId  string `json:"repoId"`
@@ -77,7 +62,7 @@ func TestMaximSDKTrace(t *testing.T) {
 		t.Fatal(err)
 	}
 	defer logger.Cleanup()
-	traceId := uuid()
+	traceId := logging.NewID()
 	traceConfig := &logging.TraceConfig{Id: traceId}
 	trace := logger.Trace(traceConfig)
 	if trace.Id() != traceId {
@@ -101,10 +86,10 @@ func TestMaximSDKSession(t *testing.T) {
 		t.Fatal(err)
 	}
 	defer logger.Cleanup()
-	sessionId := uuid()
+	sessionId := logging.NewID()
 	sessionConfig := &logging.SessionConfig{Id: sessionId}
 	session := logger.Session(sessionConfig)
-	traceId := uuid()
+	traceId := logging.NewID()
 	traceConfig := &logging.TraceConfig{Id: traceId}
 	trace := session.AddTrace(traceConfig)
 	if session.Id() != sessionId {
@@ -132,10 +117,10 @@ func TestMaximSDKSessionChanges(t *testing.T) {
 		t.Fatal(err)
 	}
 	defer logger.Cleanup()
-	sessionId := uuid()
+	sessionId := logging.NewID()
 	sessionConfig := &logging.SessionConfig{Id: sessionId}
 	session := logger.Session(sessionConfig)
-	traceId := uuid()
+	traceId := logging.NewID()
 	traceConfig := &logging.TraceConfig{Id: traceId}
 	trace := session.AddTrace(traceConfig)
 	if session.Id() != sessionId {
@@ -164,7 +149,7 @@ func TestMaximSDKUnendedSession(t *testing.T) {
 		t.Fatal(err)
 	}
 	defer logger.Cleanup()
-	sessionId := uuid()
+	sessionId := logging.NewID()
 	sessionConfig := &logging.SessionConfig{Id: sessionId}
 	session := logger.Session(sessionConfig)
 	time.Sleep(100 * time.Millisecond)
@@ -185,17 +170,17 @@ func TestMaximSDKTraceWithGeneration(t *testing.T) {
 		t.Fatal(err)
 	}
 	defer logger.Cleanup()
-	traceId := uuid()
+	traceId := logging.NewID()
 	trace := logger.Trace(&logging.TraceConfig{Id: traceId})
 
 	time.Sleep(2 * time.Second)
 	trace.End()
 	logger.AddTagToTrace(trace.Id(), "test", "yes")
-	logger.AddEventToTrace(trace.Id(), uuid(), "test event", nil)
+	logger.AddEventToTrace(trace.Id(), logging.NewID(), "test event", nil)
 
 	time.Sleep(40 * time.Second)
 
-	generationId := uuid()
+	generationId := logging.NewID()
 
 	message := logging.CompletionRequest{
 		Role:    "user",
@@ -245,21 +230,21 @@ func TestMaximSDKOutOfOrderMessages(t *testing.T) {
 	}
 	defer logger.Cleanup()
 
-	sessionId := uuid()
+	sessionId := logging.NewID()
 	session := logger.Session(&logging.SessionConfig{Id: sessionId})
 
-	traceId := uuid()
+	traceId := logging.NewID()
 	trace := session.AddTrace(&logging.TraceConfig{Id: traceId})
 
 	time.Sleep(2 * time.Second)
 	trace.End()
 
 	logger.AddTagToTrace(trace.Id(), "test", "yes")
-	logger.AddEventToTrace(trace.Id(), uuid(), "test event", nil)
+	logger.AddEventToTrace(trace.Id(), logging.NewID(), "test event", nil)
 
 	time.Sleep(40 * time.Second)
 
-	generationId := uuid()
+	generationId := logging.NewID()
 
 	message := logging.CompletionRequest{
 		Role:    "user",
@@ -277,7 +262,7 @@ func TestMaximSDKOutOfOrderMessages(t *testing.T) {
 	time.Sleep(30 * time.Second)
 
 	logger.AddResultToGeneration(generationId, map[string]interface{}{
-		"id":      uuid(),
+		"id":      logging.NewID(),
 		"object":  "text_completion",
 		"created": 1720353381,
 		"model":   "gpt-35-turbo",
@@ -297,10 +282,10 @@ func TestMaximSDKOutOfOrderMessages(t *testing.T) {
 
 	time.Sleep(20 * time.Second)
 
-	span1Id := uuid()
+	span1Id := logging.NewID()
 	logger.AddSpanToTrace(trace.Id(), &logging.SpanConfig{Id: span1Id, Name: maxim.StrPtr("Test Span")})
 
-	generation2Id := uuid()
+	generation2Id := logging.NewID()
 	secondMessage := logging.CompletionRequest{
 		Role:    "user",
 		Content: "Hello, how can I help you today?",
@@ -338,9 +323,9 @@ func TestMaximSDKOutOfOrderMessages(t *testing.T) {
 	time.Sleep(10 * time.Second)
 
 	logger.AddTagToSpan(span1Id, "test", "test-span")
-	logger.AddEventToSpan(span1Id, uuid(), "test-event", nil)
+	logger.AddEventToSpan(span1Id, logging.NewID(), "test-event", nil)
 
-	retrievalId := uuid()
+	retrievalId := logging.NewID()
 	logger.AddRetrievalToSpan(span1Id, &logging.RetrievalConfig{Id: retrievalId, Name: maxim.StrPtr("Test Retrieval")})
 	logger.SetRetrievalInput(retrievalId, "asdasdas")
 	logger.SetRetrievalOutput(retrievalId, []string{"asdasdas", "asdasdas", "asdasdas"})
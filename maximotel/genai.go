@@ -0,0 +1,313 @@
+package maximotel
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/maximhq/maxim-go/logging"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// GenAI semantic-convention attribute keys.
+// See https://opentelemetry.io/docs/specs/semconv/gen-ai/
+const (
+	attrSystem                = "gen_ai.system"
+	attrProviderName          = "gen_ai.provider.name"
+	attrOperationName         = "gen_ai.operation.name"
+	attrConversationId        = "gen_ai.conversation.id"
+	attrRequestModel          = "gen_ai.request.model"
+	attrResponseModel         = "gen_ai.response.model"
+	attrResponseId            = "gen_ai.response.id"
+	attrResponseFinishReasons = "gen_ai.response.finish_reasons"
+	attrUsageInputTokens      = "gen_ai.usage.input_tokens"
+	attrUsageOutputTokens     = "gen_ai.usage.output_tokens"
+	attrUsagePromptTokens     = "gen_ai.usage.prompt_tokens"
+	attrUsageCompletionTokens = "gen_ai.usage.completion_tokens"
+	attrErrorType             = "error.type"
+	attrSessionId             = "session.id"
+
+	requestParameterPrefix = "gen_ai.request."
+	promptPrefix           = "gen_ai.prompt."
+	completionPrefix       = "gen_ai.completion."
+)
+
+// GenAI semantic-convention event names carrying prompt and completion content.
+const (
+	eventSystemMessage     = "gen_ai.system.message"
+	eventUserMessage       = "gen_ai.user.message"
+	eventAssistantMessage  = "gen_ai.assistant.message"
+	eventToolMessage       = "gen_ai.tool.message"
+	eventChoice            = "gen_ai.choice"
+	eventContentPrompt     = "gen_ai.content.prompt"
+	eventContentCompletion = "gen_ai.content.completion"
+)
+
+var messageEventRoles = map[string]string{
+	eventSystemMessage:    "system",
+	eventUserMessage:      "user",
+	eventAssistantMessage: "assistant",
+	eventToolMessage:      "tool",
+}
+
+func isGenerationSpan(attrs []attribute.KeyValue) bool {
+	for _, kv := range attrs {
+		switch string(kv.Key) {
+		case attrRequestModel, attrSystem, attrProviderName:
+			return true
+		}
+	}
+	return false
+}
+
+func isGenAIContentEvent(name string) bool {
+	if _, ok := messageEventRoles[name]; ok {
+		return true
+	}
+	return name == eventChoice || name == eventContentPrompt || name == eventContentCompletion
+}
+
+func sessionIdFromAttributes(attrs []attribute.KeyValue) (string, bool) {
+	for _, kv := range attrs {
+		switch string(kv.Key) {
+		case attrSessionId, attrConversationId:
+			return kv.Value.Emit(), true
+		}
+	}
+	return "", false
+}
+
+// tagsFromAttributes converts attributes that are not consumed by the GenAI
+// mapping into Maxim tags.
+func tagsFromAttributes(attrs []attribute.KeyValue) map[string]string {
+	tags := map[string]string{}
+	for _, kv := range attrs {
+		key := string(kv.Key)
+		if strings.HasPrefix(key, "gen_ai.") {
+			continue
+		}
+		tags[key] = kv.Value.Emit()
+	}
+	return tags
+}
+
+func attributeMap(attrs []attribute.KeyValue) map[string]attribute.Value {
+	m := make(map[string]attribute.Value, len(attrs))
+	for _, kv := range attrs {
+		m[string(kv.Key)] = kv.Value
+	}
+	return m
+}
+
+func generationConfigFromSpan(s sdktrace.ReadOnlySpan) *logging.GenerationConfig {
+	attrs := attributeMap(s.Attributes())
+	name := s.Name()
+	startTimestamp := s.StartTime()
+	gc := &logging.GenerationConfig{
+		Id:              s.SpanContext().SpanID().String(),
+		Name:            &name,
+		Model:           attrs[attrRequestModel].AsString(),
+		Provider:        attrs[attrProviderName].AsString(),
+		Messages:        promptMessages(s, attrs),
+		ModelParameters: map[string]interface{}{},
+		StartTimestamp:  &startTimestamp,
+	}
+	if gc.Provider == "" {
+		gc.Provider = attrs[attrSystem].AsString()
+	}
+	if gc.Model == "" {
+		gc.Model = attrs[attrResponseModel].AsString()
+	}
+	for key, value := range attrs {
+		if !strings.HasPrefix(key, requestParameterPrefix) || key == attrRequestModel {
+			continue
+		}
+		gc.ModelParameters[strings.TrimPrefix(key, requestParameterPrefix)] = value.AsInterface()
+	}
+	if tags := tagsFromAttributes(s.Attributes()); len(tags) > 0 {
+		gc.Tags = &tags
+	}
+	if operation, ok := attrs[attrOperationName]; ok {
+		if gc.Tags == nil {
+			gc.Tags = &map[string]string{}
+		}
+		(*gc.Tags)[attrOperationName] = operation.AsString()
+	}
+	return gc
+}
+
+// promptMessages collects the request messages of a GenAI span from, in
+// order of preference, message events, the legacy prompt content event and
+// indexed gen_ai.prompt.<n>.* attributes.
+func promptMessages(s sdktrace.ReadOnlySpan, attrs map[string]attribute.Value) []logging.CompletionRequest {
+	var messages []logging.CompletionRequest
+	for _, event := range s.Events() {
+		if role, ok := messageEventRoles[event.Name]; ok {
+			eventAttrs := attributeMap(event.Attributes)
+			if r, ok := eventAttrs["role"]; ok && r.AsString() != "" {
+				role = r.AsString()
+			}
+			messages = append(messages, logging.CompletionRequest{
+				Role:    role,
+				Content: eventAttrs["content"].AsString(),
+			})
+			continue
+		}
+		if event.Name == eventContentPrompt {
+			prompt := attributeMap(event.Attributes)["gen_ai.prompt"].AsString()
+			messages = append(messages, parseMessages(prompt, "user")...)
+		}
+	}
+	if len(messages) > 0 {
+		return messages
+	}
+	for _, m := range indexedMessages(attrs, promptPrefix) {
+		messages = append(messages, logging.CompletionRequest{Role: m.role, Content: m.content})
+	}
+	return messages
+}
+
+// parseMessages reads a JSON encoded list of {role, content} messages, falling
+// back to a single message with the given role for plain text.
+func parseMessages(raw, role string) []logging.CompletionRequest {
+	if raw == "" {
+		return nil
+	}
+	var messages []logging.CompletionRequest
+	if err := json.Unmarshal([]byte(raw), &messages); err == nil && len(messages) > 0 {
+		return messages
+	}
+	return []logging.CompletionRequest{{Role: role, Content: raw}}
+}
+
+type indexedMessage struct {
+	index        int
+	role         string
+	content      string
+	finishReason string
+}
+
+// indexedMessages reads messages recorded as <prefix><n>.role / <prefix><n>.content
+// attributes, as emitted by several GenAI instrumentation libraries.
+func indexedMessages(attrs map[string]attribute.Value, prefix string) []indexedMessage {
+	byIndex := map[int]*indexedMessage{}
+	for key, value := range attrs {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		parts := strings.SplitN(strings.TrimPrefix(key, prefix), ".", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		index, err := strconv.Atoi(parts[0])
+		if err != nil {
+			continue
+		}
+		m, ok := byIndex[index]
+		if !ok {
+			m = &indexedMessage{index: index}
+			byIndex[index] = m
+		}
+		switch parts[1] {
+		case "role":
+			m.role = value.AsString()
+		case "content":
+			m.content = value.AsString()
+		case "finish_reason":
+			m.finishReason = value.AsString()
+		}
+	}
+	messages := make([]indexedMessage, 0, len(byIndex))
+	for _, m := range byIndex {
+		messages = append(messages, *m)
+	}
+	sort.Slice(messages, func(i, j int) bool { return messages[i].index < messages[j].index })
+	return messages
+}
+
+// resultFromSpan builds a chat completion result from the response attributes
+// and completion events of a GenAI span. It returns nil when the span carries
+// no response information at all.
+func resultFromSpan(s sdktrace.ReadOnlySpan) *logging.ChatCompletionResult {
+	attrs := attributeMap(s.Attributes())
+	var finishReasons []string
+	if v, ok := attrs[attrResponseFinishReasons]; ok {
+		finishReasons = v.AsStringSlice()
+	}
+	var choices []logging.ChatCompletionChoice
+	addChoice := func(role, content, finishReason string) {
+		index := len(choices)
+		if finishReason == "" && index < len(finishReasons) {
+			finishReason = finishReasons[index]
+		}
+		if role == "" {
+			role = "assistant"
+		}
+		choices = append(choices, logging.ChatCompletionChoice{
+			Index:        index,
+			Messages:     []logging.ChatCompletionMessage{{Role: role, Content: &content}},
+			FinishReason: finishReason,
+		})
+	}
+	for _, event := range s.Events() {
+		eventAttrs := attributeMap(event.Attributes)
+		switch event.Name {
+		case eventChoice:
+			content := eventAttrs["content"].AsString()
+			if content == "" {
+				content = eventAttrs["message"].AsString()
+			}
+			addChoice(eventAttrs["role"].AsString(), content, eventAttrs["finish_reason"].AsString())
+		case eventContentCompletion:
+			for _, m := range parseMessages(eventAttrs["gen_ai.completion"].AsString(), "assistant") {
+				content, _ := m.Content.(string)
+				addChoice(m.Role, content, "")
+			}
+		}
+	}
+	if len(choices) == 0 {
+		for _, m := range indexedMessages(attrs, completionPrefix) {
+			addChoice(m.role, m.content, m.finishReason)
+		}
+	}
+	usage := logging.Usage{
+		PromptTokens:     int(firstInt(attrs, attrUsageInputTokens, attrUsagePromptTokens)),
+		CompletionTokens: int(firstInt(attrs, attrUsageOutputTokens, attrUsageCompletionTokens)),
+	}
+	usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+	if len(choices) == 0 && usage.TotalTokens == 0 {
+		return nil
+	}
+	model := attrs[attrResponseModel].AsString()
+	if model == "" {
+		model = attrs[attrRequestModel].AsString()
+	}
+	return &logging.ChatCompletionResult{
+		ID:      attrs[attrResponseId].AsString(),
+		Object:  "chat.completion",
+		Created: s.EndTime().Unix(),
+		Model:   model,
+		Choices: choices,
+		Usage:   usage,
+	}
+}
+
+func generationErrorFromSpan(s sdktrace.ReadOnlySpan) *logging.GenerationError {
+	ge := &logging.GenerationError{Message: s.Status().Description}
+	if v, ok := attributeMap(s.Attributes())[attrErrorType]; ok {
+		errorType := v.AsString()
+		ge.Type = &errorType
+	}
+	return ge
+}
+
+func firstInt(attrs map[string]attribute.Value, keys ...string) int64 {
+	for _, key := range keys {
+		if v, ok := attrs[key]; ok {
+			return v.AsInt64()
+		}
+	}
+	return 0
+}
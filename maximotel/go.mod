@@ -0,0 +1,20 @@
+// To build against the SDK in this repository instead of the required
+// release, use a workspace: go work init . ./maximotel ./maximprom
+module github.com/maximhq/maxim-go/maximotel
+
+go 1.21
+
+require (
+	github.com/maximhq/maxim-go v0.1.14
+	go.opentelemetry.io/otel v1.28.0
+	go.opentelemetry.io/otel/sdk v1.28.0
+	go.opentelemetry.io/otel/trace v1.28.0
+)
+
+require (
+	github.com/go-logr/logr v1.4.2 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	go.opentelemetry.io/otel/metric v1.28.0 // indirect
+	golang.org/x/sys v0.21.0 // indirect
+)
@@ -0,0 +1,375 @@
+// Package maximotel bridges OpenTelemetry tracing into Maxim.
+//
+// Register a SpanProcessor with an OpenTelemetry TracerProvider and every
+// span recorded through the existing OTel instrumentation is mirrored into a
+// Maxim logger: root spans become traces, child spans become spans, and spans
+// carrying GenAI semantic-convention attributes become generations.
+package maximotel
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/maximhq/maxim-go/logging"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type nodeKind int
+
+const (
+	nodeTrace nodeKind = iota
+	nodeSpan
+	nodeGeneration
+)
+
+// node is the Maxim entity an OTel span was mapped to: the handles created
+// when the span started, the container its own children have to be attached
+// to, and what was already recorded at the start.
+type node struct {
+	kind          nodeKind
+	traceId       string
+	containerId   string
+	containerKind nodeKind
+	trace         *logging.Trace
+	span          *logging.Span
+	generation    *logging.Generation
+	tags          map[string]string
+	model         string
+	messages      int
+	parameters    int
+}
+
+// SpanProcessor is an sdktrace.SpanProcessor that records OpenTelemetry spans
+// as Maxim traces, spans and generations.
+//
+// A processor is used instead of an exporter because the parent/child shape
+// has to be resolved when spans start: exporters only ever see finished spans,
+// children before their parents. Every entity is therefore created in OnStart,
+// after its parent, and OnEnd only records what the span collected since.
+type SpanProcessor struct {
+	logger *logging.Logger
+	mutex  sync.Mutex
+	nodes  map[trace.SpanID]*node
+	// traces counts the open spans of every Maxim trace created, so spans
+	// whose parent is not open, e.g. async work outliving its parent, are
+	// added to the existing trace instead of creating it again.
+	traces map[string]int
+	// ended remembers the last maxEndedTraces traces without open spans,
+	// by the sequence number of their entry in endedOrder.
+	ended      map[string]uint64
+	endedOrder []endedTrace
+	endedSeq   uint64
+}
+
+type endedTrace struct {
+	traceId string
+	seq     uint64
+}
+
+// maxEndedTraces bounds how many traces without open spans are remembered.
+// Spans started under older traces create the trace again.
+const maxEndedTraces = 4096
+
+var _ sdktrace.SpanProcessor = (*SpanProcessor)(nil)
+
+// NewSpanProcessor creates a SpanProcessor writing into the given logger.
+func NewSpanProcessor(logger *logging.Logger) *SpanProcessor {
+	return &SpanProcessor{
+		logger: logger,
+		nodes:  map[trace.SpanID]*node{},
+		traces: map[string]int{},
+		ended:  map[string]uint64{},
+	}
+}
+
+// OnStart creates the Maxim trace, span or generation for the span and
+// resolves where the span's children will be attached.
+func (p *SpanProcessor) OnStart(parent context.Context, s sdktrace.ReadWriteSpan) {
+	sc := s.SpanContext()
+	if !sc.IsValid() {
+		return
+	}
+	traceId := sc.TraceID().String()
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	var parentNode *node
+	if s.Parent().IsValid() && !s.Parent().IsRemote() {
+		parentNode = p.nodes[s.Parent().SpanID()]
+	}
+	if parentNode == nil && !p.knownTrace(traceId) {
+		n := &node{
+			kind:          nodeTrace,
+			traceId:       traceId,
+			containerId:   traceId,
+			containerKind: nodeTrace,
+		}
+		p.startTrace(n, s)
+		p.addNode(sc.SpanID(), n)
+		return
+	}
+	if parentNode == nil {
+		// The parent already ended, or is remote, but the trace was created
+		// by another span: the span is added to it.
+		parentNode = &node{containerId: traceId, containerKind: nodeTrace}
+	}
+	n := &node{
+		kind:          nodeSpan,
+		traceId:       traceId,
+		containerId:   sc.SpanID().String(),
+		containerKind: nodeSpan,
+	}
+	if isGenerationSpan(s.Attributes()) {
+		// Generations cannot hold children, so anything started under a
+		// generation is attached to the generation's own container.
+		n.kind = nodeGeneration
+		n.containerId = parentNode.containerId
+		n.containerKind = parentNode.containerKind
+		p.startGeneration(n, parentNode.containerId, parentNode.containerKind, s)
+	} else {
+		p.startSpan(n, parentNode.containerId, parentNode.containerKind, s)
+	}
+	p.addNode(sc.SpanID(), n)
+}
+
+// knownTrace reports whether the Maxim trace was already created. It must
+// be called with the mutex held.
+func (p *SpanProcessor) knownTrace(traceId string) bool {
+	if p.traces[traceId] > 0 {
+		return true
+	}
+	_, ok := p.ended[traceId]
+	return ok
+}
+
+// addNode records the node of a started span. It must be called with the
+// mutex held.
+func (p *SpanProcessor) addNode(spanId trace.SpanID, n *node) {
+	p.nodes[spanId] = n
+	p.traces[n.traceId]++
+	delete(p.ended, n.traceId)
+}
+
+// removeNode forgets the node of an ended span, and remembers its trace
+// once it has no open spans left. It must be called with the mutex held.
+func (p *SpanProcessor) removeNode(spanId trace.SpanID) (*node, bool) {
+	n, ok := p.nodes[spanId]
+	if !ok {
+		return nil, false
+	}
+	delete(p.nodes, spanId)
+	if p.traces[n.traceId]--; p.traces[n.traceId] > 0 {
+		return n, true
+	}
+	delete(p.traces, n.traceId)
+	p.endedSeq++
+	p.ended[n.traceId] = p.endedSeq
+	p.endedOrder = append(p.endedOrder, endedTrace{traceId: n.traceId, seq: p.endedSeq})
+	for len(p.endedOrder) > maxEndedTraces {
+		oldest := p.endedOrder[0]
+		p.endedOrder = p.endedOrder[1:]
+		// The entry is stale when the trace got spans again since.
+		if p.ended[oldest.traceId] == oldest.seq {
+			delete(p.ended, oldest.traceId)
+		}
+	}
+	return n, true
+}
+
+func (p *SpanProcessor) startTrace(n *node, s sdktrace.ReadWriteSpan) {
+	name := s.Name()
+	startTimestamp := s.StartTime()
+	tc := &logging.TraceConfig{
+		Id:             n.traceId,
+		Name:           &name,
+		StartTimestamp: &startTimestamp,
+	}
+	if sessionId, ok := sessionIdFromAttributes(s.Attributes()); ok {
+		tc.SessionId = &sessionId
+	}
+	if isGenerationSpan(s.Attributes()) {
+		// A root GenAI span becomes a trace holding a single generation,
+		// which carries the span's attributes.
+		n.trace = p.logger.Trace(tc)
+		p.startGeneration(n, n.traceId, nodeTrace, s)
+		return
+	}
+	n.tags = tagsFromAttributes(s.Attributes())
+	if len(n.tags) > 0 {
+		tags := copyTags(n.tags)
+		tc.Tags = &tags
+	}
+	n.trace = p.logger.Trace(tc)
+}
+
+func (p *SpanProcessor) startSpan(n *node, parentId string, parentKind nodeKind, s sdktrace.ReadWriteSpan) {
+	name := s.Name()
+	startTimestamp := s.StartTime()
+	sc := &logging.SpanConfig{
+		Id:             n.containerId,
+		Name:           &name,
+		StartTimestamp: &startTimestamp,
+	}
+	n.tags = tagsFromAttributes(s.Attributes())
+	if len(n.tags) > 0 {
+		tags := copyTags(n.tags)
+		sc.Tags = &tags
+	}
+	if parentKind == nodeTrace {
+		n.span = p.logger.AddSpanToTrace(parentId, sc)
+	} else {
+		n.span = p.logger.AddSubSpanToSpan(parentId, sc)
+	}
+}
+
+func (p *SpanProcessor) startGeneration(n *node, parentId string, parentKind nodeKind, s sdktrace.ReadWriteSpan) {
+	gc := generationConfigFromSpan(s)
+	n.model = gc.Model
+	n.messages = len(gc.Messages)
+	n.parameters = len(gc.ModelParameters)
+	if gc.Tags != nil {
+		n.tags = copyTags(*gc.Tags)
+	}
+	if parentKind == nodeTrace {
+		n.generation = p.logger.AddGenerationToTrace(parentId, gc)
+	} else {
+		n.generation = p.logger.AddGenerationToSpan(parentId, gc)
+	}
+}
+
+// OnEnd records what the span collected after it started and ends its Maxim
+// entity.
+func (p *SpanProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	sc := s.SpanContext()
+	if !sc.IsValid() {
+		return
+	}
+	p.mutex.Lock()
+	n, ok := p.removeNode(sc.SpanID())
+	p.mutex.Unlock()
+	if !ok {
+		// The span started before the processor was registered.
+		return
+	}
+	switch n.kind {
+	case nodeTrace:
+		p.endTrace(n, s)
+	case nodeSpan:
+		p.endSpan(n, s)
+	case nodeGeneration:
+		p.endGeneration(n, s)
+	}
+}
+
+func (p *SpanProcessor) endTrace(n *node, s sdktrace.ReadOnlySpan) {
+	if n.generation != nil {
+		p.endGeneration(n, s)
+	} else {
+		addNewTags(n.trace.AddTag, n.tags, tagsFromAttributes(s.Attributes()))
+		p.recordEvents(logging.EntityTrace, n.traceId, s)
+	}
+	if s.Status().Code == codes.Error {
+		n.trace.AddTag("error", s.Status().Description)
+	}
+	n.trace.EndAt(s.EndTime())
+}
+
+func (p *SpanProcessor) endSpan(n *node, s sdktrace.ReadOnlySpan) {
+	tags := tagsFromAttributes(s.Attributes())
+	if s.Status().Code == codes.Error {
+		tags["error"] = s.Status().Description
+	}
+	addNewTags(n.span.AddTag, n.tags, tags)
+	p.recordEvents(logging.EntitySpan, n.span.Id(), s)
+	n.span.EndAt(s.EndTime())
+}
+
+func (p *SpanProcessor) endGeneration(n *node, s sdktrace.ReadOnlySpan) {
+	generation := n.generation
+	gc := generationConfigFromSpan(s)
+	if gc.Model != n.model {
+		generation.SetModel(gc.Model)
+	}
+	if len(gc.Messages) > n.messages {
+		generation.AddMessages(gc.Messages[n.messages:])
+	}
+	if len(gc.ModelParameters) > n.parameters {
+		generation.SetModelParameters(gc.ModelParameters)
+	}
+	if gc.Tags != nil {
+		addNewTags(generation.AddTag, n.tags, *gc.Tags)
+	}
+	p.recordEvents(logging.EntityGeneration, generation.Id(), s)
+	if s.Status().Code == codes.Error {
+		generation.SetError(generationErrorFromSpan(s))
+	}
+	if result := resultFromSpan(s); result != nil {
+		generation.SetResult(result)
+	}
+	generation.EndAt(s.EndTime())
+}
+
+// addNewTags adds the tags that were not already recorded when the span
+// started.
+func addNewTags(addTag func(key, value string), recorded, tags map[string]string) {
+	for key, value := range tags {
+		if current, ok := recorded[key]; ok && current == value {
+			continue
+		}
+		addTag(key, value)
+	}
+}
+
+func copyTags(tags map[string]string) map[string]string {
+	copied := make(map[string]string, len(tags))
+	for key, value := range tags {
+		copied[key] = value
+	}
+	return copied
+}
+
+// recordEvents forwards span events that are not GenAI prompt/completion
+// events, which are already folded into generations.
+func (p *SpanProcessor) recordEvents(entity logging.Entity, entityId string, s sdktrace.ReadOnlySpan) {
+	for i, event := range s.Events() {
+		if isGenAIContentEvent(event.Name) {
+			continue
+		}
+		eventId := fmt.Sprintf("%s-%d", s.SpanContext().SpanID(), i)
+		var tags *map[string]string
+		if eventTags := tagsFromAttributes(event.Attributes); len(eventTags) > 0 {
+			tags = &eventTags
+		}
+		switch entity {
+		case logging.EntityTrace:
+			p.logger.AddEventToTrace(entityId, eventId, event.Name, tags)
+		case logging.EntitySpan:
+			p.logger.AddEventToSpan(entityId, eventId, event.Name, tags)
+		case logging.EntityGeneration:
+			p.logger.AddEventToGeneration(entityId, eventId, event.Name, tags)
+		}
+	}
+}
+
+// Shutdown flushes the logger. The logger itself is owned by the caller and
+// is not cleaned up.
+func (p *SpanProcessor) Shutdown(ctx context.Context) error {
+	return p.ForceFlush(ctx)
+}
+
+// ForceFlush pushes all pending Maxim logs to the server.
+func (p *SpanProcessor) ForceFlush(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		p.logger.Flush()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
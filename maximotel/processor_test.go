@@ -0,0 +1,172 @@
+package maximotel_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/maximhq/maxim-go/logging"
+	"github.com/maximhq/maxim-go/maximotel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func newTestLogger(t *testing.T) (*logging.Logger, func() string) {
+	var mutex sync.Mutex
+	var pushed strings.Builder
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mutex.Lock()
+		pushed.Write(body)
+		mutex.Unlock()
+		w.Write([]byte("{}"))
+	}))
+	t.Cleanup(server.Close)
+	logger := logging.NewLogger(server.URL, "test-key", &logging.LoggerConfig{Id: "test-repo"})
+	t.Cleanup(logger.Cleanup)
+	return logger, func() string {
+		logger.Flush()
+		mutex.Lock()
+		defer mutex.Unlock()
+		return pushed.String()
+	}
+}
+
+func TestSpanProcessorMapsSpansAndGenerations(t *testing.T) {
+	logger, pushed := newTestLogger(t)
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(maximotel.NewSpanProcessor(logger)))
+	tracer := provider.Tracer("test")
+
+	ctx, root := tracer.Start(context.Background(), "handle-request", trace.WithAttributes(
+		attribute.String("session.id", "session-1"),
+		attribute.String("http.route", "/chat"),
+	))
+	ctx, tool := tracer.Start(ctx, "lookup")
+	tool.End()
+	_, chat := tracer.Start(ctx, "chat gpt-4o", trace.WithAttributes(
+		attribute.String("gen_ai.system", "openai"),
+		attribute.String("gen_ai.request.model", "gpt-4o"),
+		attribute.Float64("gen_ai.request.temperature", 0.2),
+	))
+	chat.AddEvent("gen_ai.user.message", trace.WithAttributes(attribute.String("content", "hello")))
+	chat.AddEvent("gen_ai.choice", trace.WithAttributes(
+		attribute.String("content", "hi there"),
+		attribute.String("finish_reason", "stop"),
+	))
+	chat.SetAttributes(
+		attribute.Int("gen_ai.usage.input_tokens", 12),
+		attribute.Int("gen_ai.usage.output_tokens", 3),
+	)
+	chat.SetStatus(codes.Error, "rate limited")
+	chat.End()
+	root.End()
+
+	rootId := root.SpanContext().TraceID().String()
+	logs := pushed()
+	for _, want := range []string{
+		"trace{id=" + rootId + ",action=create",
+		`"sessionId":"session-1"`,
+		`"http.route":"/chat"`,
+		"trace{id=" + rootId + ",action=add-span",
+		`"id":"` + tool.SpanContext().SpanID().String() + `"`,
+		"trace{id=" + rootId + ",action=add-generation",
+		`"model":"gpt-4o"`,
+		`"provider":"openai"`,
+		`"temperature":0.2`,
+		`"content":"hello"`,
+		"generation{id=" + chat.SpanContext().SpanID().String() + ",action=result",
+		`"total_tokens":15`,
+		`"message":"rate limited"`,
+		"trace{id=" + rootId + ",action=end",
+	} {
+		if !strings.Contains(logs, want) {
+			t.Errorf("pushed logs missing %q\n%s", want, logs)
+		}
+	}
+}
+
+func TestSpanProcessorAttachesGenerationChildrenToContainer(t *testing.T) {
+	logger, pushed := newTestLogger(t)
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(maximotel.NewSpanProcessor(logger)))
+	tracer := provider.Tracer("test")
+
+	ctx, root := tracer.Start(context.Background(), "agent")
+	ctx, step := tracer.Start(ctx, "step")
+	ctx, chat := tracer.Start(ctx, "chat", trace.WithAttributes(attribute.String("gen_ai.request.model", "gpt-4o")))
+	_, call := tracer.Start(ctx, "tool-call")
+	call.End()
+	chat.End()
+	step.End()
+	root.End()
+
+	logs := pushed()
+	want := "span{id=" + step.SpanContext().SpanID().String() + ",action=add-span,data={"
+	if !strings.Contains(logs, want) || !strings.Contains(logs, call.SpanContext().SpanID().String()) {
+		t.Errorf("tool call was not attached to the enclosing span\n%s", logs)
+	}
+}
+
+func TestSpanProcessorCreatesParentsBeforeChildren(t *testing.T) {
+	logger, pushed := newTestLogger(t)
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(maximotel.NewSpanProcessor(logger)))
+	tracer := provider.Tracer("test")
+
+	ctx, root := tracer.Start(context.Background(), "agent")
+	ctx, step := tracer.Start(ctx, "step")
+	_, call := tracer.Start(ctx, "tool-call")
+	call.End()
+	step.End()
+	root.End()
+
+	logs := pushed()
+	stepId := step.SpanContext().SpanID().String()
+	parent := strings.Index(logs, "trace{id="+root.SpanContext().TraceID().String()+",action=add-span")
+	child := strings.Index(logs, "span{id="+stepId+",action=add-span")
+	if parent < 0 || child < 0 || child < parent {
+		t.Errorf("nested span was added before its parent\n%s", logs)
+	}
+}
+
+func TestSpanProcessorAddsOrphanedSpansToTheTrace(t *testing.T) {
+	logger, pushed := newTestLogger(t)
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(maximotel.NewSpanProcessor(logger)))
+	tracer := provider.Tracer("test")
+
+	ctx, root := tracer.Start(context.Background(), "handle-request")
+	root.End()
+	_, async := tracer.Start(ctx, "send-email")
+	async.End()
+
+	remote := trace.ContextWithRemoteSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1},
+		SpanID:     trace.SpanID{1},
+		TraceFlags: trace.FlagsSampled,
+		Remote:     true,
+	}))
+	_, first := tracer.Start(remote, "first")
+	_, second := tracer.Start(remote, "second")
+	second.End()
+	first.End()
+
+	logs := pushed()
+	for _, traceId := range []string{root.SpanContext().TraceID().String(), trace.TraceID{1}.String()} {
+		if n := strings.Count(logs, "trace{id="+traceId+",action=create"); n != 1 {
+			t.Errorf("expected trace %s to be created once, got %d\n%s", traceId, n, logs)
+		}
+		if n := strings.Count(logs, "trace{id="+traceId+",action=end"); n != 1 {
+			t.Errorf("expected trace %s to end once, got %d\n%s", traceId, n, logs)
+		}
+	}
+	for _, s := range []trace.Span{async, second} {
+		want := "action=add-span,data={\"id\":\"" + s.SpanContext().SpanID().String() + "\""
+		if !strings.Contains(logs, want) {
+			t.Errorf("span %s was not added to its trace\n%s", s.SpanContext().SpanID(), logs)
+		}
+	}
+}
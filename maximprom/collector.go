@@ -0,0 +1,128 @@
+// Package maximprom exposes the health of Maxim loggers as Prometheus metrics.
+package maximprom
+
+import (
+	"sync"
+
+	"github.com/maximhq/maxim-go/logging"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	queueDepthDesc = prometheus.NewDesc(
+		"maxim_sdk_queue_depth", "Commits waiting for the next flush.", []string{"repo_id"}, nil)
+	commitsDesc = prometheus.NewDesc(
+		"maxim_sdk_commits_total", "Commits accepted into the queue.", []string{"repo_id"}, nil)
+	commitRateDesc = prometheus.NewDesc(
+		"maxim_sdk_commits_per_second", "Commit rate over the last flush interval.", []string{"repo_id"}, nil)
+	flushesDesc = prometheus.NewDesc(
+		"maxim_sdk_flushes_total", "Flushes that pushed at least one log.", []string{"repo_id"}, nil)
+	flushLatencyDesc = prometheus.NewDesc(
+		"maxim_sdk_last_flush_latency_seconds", "Duration of the last non-empty flush.", []string{"repo_id"}, nil)
+	bytesPushedDesc = prometheus.NewDesc(
+		"maxim_sdk_pushed_bytes_total", "Bytes of logs accepted by the server.", []string{"repo_id"}, nil)
+	pushFailuresDesc = prometheus.NewDesc(
+		"maxim_sdk_push_failures_total", "Batches not accepted by the server after all attempts.", []string{"repo_id"}, nil)
+	spoolFilesDesc = prometheus.NewDesc(
+		"maxim_sdk_spool_files", "Batches spooled to disk waiting to be pushed again.", []string{"repo_id"}, nil)
+	spoolBytesDesc = prometheus.NewDesc(
+		"maxim_sdk_spool_bytes", "Size of the batches spooled to disk.", []string{"repo_id"}, nil)
+	droppedDesc = prometheus.NewDesc(
+		"maxim_sdk_dropped_total", "Logs lost for good.", []string{"repo_id", "reason"}, nil)
+)
+
+// Collector is a prometheus.Collector reporting the Stats of Maxim loggers.
+// Loggers writing to the same log repository are reported as a single
+// series per metric, with their stats summed.
+type Collector struct {
+	mutex   sync.RWMutex
+	loggers []*logging.Logger
+}
+
+var _ prometheus.Collector = (*Collector)(nil)
+
+// NewCollector creates a Collector for the given loggers.
+//
+//	prometheus.MustRegister(maximprom.NewCollector(logger))
+func NewCollector(loggers ...*logging.Logger) *Collector {
+	c := &Collector{}
+	for _, logger := range loggers {
+		c.Add(logger)
+	}
+	return c
+}
+
+// Add starts reporting the stats of another logger. Adding a logger that is
+// already reported has no effect.
+func (c *Collector) Add(logger *logging.Logger) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for _, l := range c.loggers {
+		if l == logger {
+			return
+		}
+	}
+	c.loggers = append(c.loggers, logger)
+}
+
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- queueDepthDesc
+	ch <- commitsDesc
+	ch <- commitRateDesc
+	ch <- flushesDesc
+	ch <- flushLatencyDesc
+	ch <- bytesPushedDesc
+	ch <- pushFailuresDesc
+	ch <- spoolFilesDesc
+	ch <- spoolBytesDesc
+	ch <- droppedDesc
+}
+
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.mutex.RLock()
+	var repoIds []string
+	stats := map[string]*logging.LoggerStats{}
+	for _, logger := range c.loggers {
+		s := logger.Stats()
+		if total, ok := stats[s.RepoId]; ok {
+			mergeStats(total, &s)
+			continue
+		}
+		repoIds = append(repoIds, s.RepoId)
+		stats[s.RepoId] = &s
+	}
+	c.mutex.RUnlock()
+	for _, repoId := range repoIds {
+		s := stats[repoId]
+		ch <- prometheus.MustNewConstMetric(queueDepthDesc, prometheus.GaugeValue, float64(s.QueueDepth), s.RepoId)
+		ch <- prometheus.MustNewConstMetric(commitsDesc, prometheus.CounterValue, float64(s.Committed), s.RepoId)
+		ch <- prometheus.MustNewConstMetric(commitRateDesc, prometheus.GaugeValue, s.CommitsPerSecond, s.RepoId)
+		ch <- prometheus.MustNewConstMetric(flushesDesc, prometheus.CounterValue, float64(s.Flushes), s.RepoId)
+		ch <- prometheus.MustNewConstMetric(flushLatencyDesc, prometheus.GaugeValue, s.LastFlushLatency.Seconds(), s.RepoId)
+		ch <- prometheus.MustNewConstMetric(bytesPushedDesc, prometheus.CounterValue, float64(s.BytesPushed), s.RepoId)
+		ch <- prometheus.MustNewConstMetric(pushFailuresDesc, prometheus.CounterValue, float64(s.PushFailures), s.RepoId)
+		ch <- prometheus.MustNewConstMetric(spoolFilesDesc, prometheus.GaugeValue, float64(s.SpoolFiles), s.RepoId)
+		ch <- prometheus.MustNewConstMetric(spoolBytesDesc, prometheus.GaugeValue, float64(s.SpoolBytes), s.RepoId)
+		for reason, n := range s.Dropped {
+			ch <- prometheus.MustNewConstMetric(droppedDesc, prometheus.CounterValue, float64(n), s.RepoId, reason)
+		}
+	}
+}
+
+// mergeStats adds the stats of another logger of the same repository to
+// total. Loggers of a repository share its spool directory, so the spool is
+// counted once.
+func mergeStats(total, s *logging.LoggerStats) {
+	total.QueueDepth += s.QueueDepth
+	total.Committed += s.Committed
+	total.CommitsPerSecond += s.CommitsPerSecond
+	total.Flushes += s.Flushes
+	if s.LastFlushLatency > total.LastFlushLatency {
+		total.LastFlushLatency = s.LastFlushLatency
+	}
+	total.BytesPushed += s.BytesPushed
+	total.PushFailures += s.PushFailures
+	for reason, n := range s.Dropped {
+		total.Dropped[reason] += n
+	}
+}
@@ -0,0 +1,70 @@
+package maximprom_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/maximhq/maxim-go/logging"
+	"github.com/maximhq/maxim-go/maximprom"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCollectorReportsLoggerStats(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+	logger := logging.NewLogger(server.URL, "test-key", &logging.LoggerConfig{Id: "test-repo", MaxQueueSize: 2})
+	defer logger.Cleanup()
+	trace := logger.Trace(&logging.TraceConfig{Id: "trace-1"})
+	trace.SetInput("hello")
+	trace.End()
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(maximprom.NewCollector(logger))
+	expected := `
+# HELP maxim_sdk_commits_total Commits accepted into the queue.
+# TYPE maxim_sdk_commits_total counter
+maxim_sdk_commits_total{repo_id="test-repo"} 2
+# HELP maxim_sdk_dropped_total Logs lost for good.
+# TYPE maxim_sdk_dropped_total counter
+maxim_sdk_dropped_total{reason="queue_full",repo_id="test-repo"} 1
+# HELP maxim_sdk_queue_depth Commits waiting for the next flush.
+# TYPE maxim_sdk_queue_depth gauge
+maxim_sdk_queue_depth{repo_id="test-repo"} 2
+`
+	err := testutil.GatherAndCompare(registry, strings.NewReader(expected),
+		"maxim_sdk_commits_total", "maxim_sdk_dropped_total", "maxim_sdk_queue_depth")
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCollectorMergesLoggersOfTheSameRepo(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+	first := logging.NewLogger(server.URL, "test-key", &logging.LoggerConfig{Id: "shared-repo"})
+	defer first.Cleanup()
+	second := logging.NewLogger(server.URL, "test-key", &logging.LoggerConfig{Id: "shared-repo"})
+	defer second.Cleanup()
+	first.Trace(&logging.TraceConfig{Id: "trace-1"})
+	second.Trace(&logging.TraceConfig{Id: "trace-2"})
+
+	collector := maximprom.NewCollector(first, second)
+	collector.Add(first)
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collector)
+	expected := `
+# HELP maxim_sdk_commits_total Commits accepted into the queue.
+# TYPE maxim_sdk_commits_total counter
+maxim_sdk_commits_total{repo_id="shared-repo"} 2
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "maxim_sdk_commits_total"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,21 @@
+// To build against the SDK in this repository instead of the required
+// release, use a workspace: go work init . ./maximotel ./maximprom
+module github.com/maximhq/maxim-go/maximprom
+
+go 1.21
+
+require (
+	github.com/maximhq/maxim-go v0.1.14
+	github.com/prometheus/client_golang v1.19.1
+)
+
+require (
+	github.com/beorn7/perks v1.0.1 // indirect
+	github.com/cespare/xxhash/v2 v2.2.0 // indirect
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/prometheus/client_model v0.5.0 // indirect
+	github.com/prometheus/common v0.48.0 // indirect
+	github.com/prometheus/procfs v0.12.0 // indirect
+	golang.org/x/sys v0.21.0 // indirect
+	google.golang.org/protobuf v1.33.0 // indirect
+)
@@ -0,0 +1,195 @@
+package maxim
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Environment variables read by New. Options override them.
+const (
+	EnvAPIKey        = "MAXIM_API_KEY"
+	EnvBaseURL       = "MAXIM_BASE_URL"
+	EnvLogRepoId     = "MAXIM_LOG_REPO_ID"
+	EnvDebug         = "MAXIM_DEBUG"
+	EnvFlushInterval = "MAXIM_FLUSH_INTERVAL"
+	EnvAutoFlush     = "MAXIM_AUTO_FLUSH"
+)
+
+const defaultBaseUrl = "https://app.getmaxim.ai"
+
+// defaultHTTPTimeout bounds every request of the client New builds when no
+// client is given, so an unresponsive API cannot hang a repository check or
+// a flush.
+const defaultHTTPTimeout = 30 * time.Second
+
+// ErrInvalidConfig is wrapped by the errors New and GetLogger return for
+// missing or malformed settings.
+var ErrInvalidConfig = errors.New("maxim: invalid config")
+
+// ErrRepoNotFound is wrapped by the errors returned when a log repository
+// cannot be verified, e.g. because it does not exist or the API key is
+// rejected.
+var ErrRepoNotFound = errors.New("maxim: log repository not found")
+
+// Option configures the SDK created by New.
+type Option func(o *options)
+
+type options struct {
+	config        MaximSDKConfig
+	repoId        string
+	flushInterval *int
+	autoFlush     *bool
+	checkRepo     bool
+}
+
+// WithAPIKey sets the API key. Defaults to MAXIM_API_KEY.
+func WithAPIKey(apiKey string) Option {
+	return func(o *options) { o.config.ApiKey = apiKey }
+}
+
+// WithBaseURL sets the URL of the Maxim API. Defaults to MAXIM_BASE_URL,
+// then to https://app.getmaxim.ai.
+func WithBaseURL(baseUrl string) Option {
+	return func(o *options) { o.config.BaseUrl = &baseUrl }
+}
+
+// WithDebug enables debug diagnostics. Defaults to MAXIM_DEBUG.
+func WithDebug(debug bool) Option {
+	return func(o *options) { o.config.Debug = debug }
+}
+
+// WithHTTPClient sets the client used for every request to the Maxim API.
+// Defaults to a client timing out after 30 seconds.
+func WithHTTPClient(client *http.Client) Option {
+	return func(o *options) { o.config.HTTPClient = client }
+}
+
+// WithLogger sets the logger receiving the SDK's own diagnostics.
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) { o.config.Logger = logger }
+}
+
+// WithDefaultTags sets tags added to every entity of every logger.
+func WithDefaultTags(tags map[string]string) Option {
+	return func(o *options) { o.config.DefaultTags = tags }
+}
+
+// WithLogRepoId sets the log repository used by GetLogger when the config
+// has no Id. Defaults to MAXIM_LOG_REPO_ID.
+func WithLogRepoId(repoId string) Option {
+	return func(o *options) { o.repoId = repoId }
+}
+
+// WithFlushInterval sets the flush interval, in seconds, of loggers that do
+// not set their own. Defaults to MAXIM_FLUSH_INTERVAL.
+func WithFlushInterval(seconds int) Option {
+	return func(o *options) { o.flushInterval = &seconds }
+}
+
+// WithAutoFlush sets whether loggers that do not say otherwise flush
+// periodically. Defaults to MAXIM_AUTO_FLUSH.
+func WithAutoFlush(autoFlush bool) Option {
+	return func(o *options) { o.autoFlush = &autoFlush }
+}
+
+// WithLazyRepoCheck creates loggers even when their log repository cannot
+// be verified, retrying the check in the background starting after
+// retryInterval (0 uses the default). See MaximSDKConfig.LazyRepoCheck.
+func WithLazyRepoCheck(retryInterval time.Duration) Option {
+	return func(o *options) {
+		o.config.LazyRepoCheck = true
+		o.config.RepoCheckRetryInterval = retryInterval
+	}
+}
+
+// WithCredentialCheck makes New verify the API key against the log
+// repository set by WithLogRepoId or MAXIM_LOG_REPO_ID, instead of waiting
+// for the first GetLogger. The result is cached.
+func WithCredentialCheck() Option {
+	return func(o *options) { o.checkRepo = true }
+}
+
+// New creates the SDK from the environment and the given options, and
+// returns an error wrapping ErrInvalidConfig when a setting is missing or
+// malformed, or ErrRepoNotFound when WithCredentialCheck fails.
+func New(opts ...Option) (*Maxim, error) {
+	o, err := optionsFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if err := o.validate(); err != nil {
+		return nil, err
+	}
+	if o.config.HTTPClient == nil {
+		o.config.HTTPClient = &http.Client{Timeout: defaultHTTPTimeout}
+	}
+	m := Init(&o.config)
+	m.repoId = o.repoId
+	m.flushInterval = o.flushInterval
+	m.autoFlush = o.autoFlush
+	if o.checkRepo {
+		if err := m.checkRepo(o.repoId); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+func optionsFromEnv() (*options, error) {
+	o := &options{}
+	o.config.ApiKey = os.Getenv(EnvAPIKey)
+	if baseUrl := os.Getenv(EnvBaseURL); baseUrl != "" {
+		o.config.BaseUrl = &baseUrl
+	}
+	o.repoId = os.Getenv(EnvLogRepoId)
+	if v := os.Getenv(EnvDebug); v != "" {
+		debug, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s=%q is not a boolean", ErrInvalidConfig, EnvDebug, v)
+		}
+		o.config.Debug = debug
+	}
+	if v := os.Getenv(EnvFlushInterval); v != "" {
+		seconds, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s=%q is not a number of seconds", ErrInvalidConfig, EnvFlushInterval, v)
+		}
+		o.flushInterval = &seconds
+	}
+	if v := os.Getenv(EnvAutoFlush); v != "" {
+		autoFlush, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s=%q is not a boolean", ErrInvalidConfig, EnvAutoFlush, v)
+		}
+		o.autoFlush = &autoFlush
+	}
+	return o, nil
+}
+
+func (o *options) validate() error {
+	if o.config.ApiKey == "" {
+		return fmt.Errorf("%w: API key is required, set %s or use WithAPIKey", ErrInvalidConfig, EnvAPIKey)
+	}
+	if o.config.BaseUrl != nil {
+		u, err := url.Parse(*o.config.BaseUrl)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("%w: base URL %q must be an absolute http(s) URL", ErrInvalidConfig, *o.config.BaseUrl)
+		}
+	}
+	if o.checkRepo && o.repoId == "" {
+		return fmt.Errorf("%w: the credential check needs a log repository, set %s or use WithLogRepoId", ErrInvalidConfig, EnvLogRepoId)
+	}
+	if o.flushInterval != nil && *o.flushInterval <= 0 {
+		return fmt.Errorf("%w: flush interval must be positive, got %d", ErrInvalidConfig, *o.flushInterval)
+	}
+	return nil
+}
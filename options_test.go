@@ -0,0 +1,143 @@
+package maxim_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/maximhq/maxim-go"
+	"github.com/maximhq/maxim-go/logging"
+)
+
+// fakeMaxim is a Maxim API counting repository checks, which answer with
+// repoStatus and repoBody.
+type fakeMaxim struct {
+	*httptest.Server
+	repoChecks atomic.Int32
+	repoStatus atomic.Int32
+	repoBody   string
+	pushes     atomic.Int32
+}
+
+func newFakeMaxim(t *testing.T) *fakeMaxim {
+	f := &fakeMaxim{repoBody: `{}`}
+	f.repoStatus.Store(http.StatusOK)
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/api/sdk/v3/log-repositories") {
+			f.repoChecks.Add(1)
+			w.WriteHeader(int(f.repoStatus.Load()))
+			w.Write([]byte(f.repoBody))
+			return
+		}
+		f.pushes.Add(1)
+		w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(f.Close)
+	return f
+}
+
+func TestNewFromEnvironment(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.Header.Get("x-maxim-api-key") != "env-key" {
+			t.Errorf("unexpected API key %q", r.Header.Get("x-maxim-api-key"))
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+	t.Setenv(maxim.EnvAPIKey, "env-key")
+	t.Setenv(maxim.EnvBaseURL, "http://invalid.example")
+	t.Setenv(maxim.EnvLogRepoId, "repo-1")
+	t.Setenv(maxim.EnvFlushInterval, "5")
+
+	mx, err := maxim.New(maxim.WithBaseURL(server.URL), maxim.WithHTTPClient(server.Client()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mx.Cleanup()
+	logger, err := mx.GetLogger(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if logger.Id() != "repo-1" {
+		t.Errorf("expected repo-1, got %q", logger.Id())
+	}
+	if requests.Load() != 1 {
+		t.Errorf("expected the repo check to use the configured client and URL, got %d requests", requests.Load())
+	}
+}
+
+func TestNewValidation(t *testing.T) {
+	for name, c := range map[string]struct {
+		env  map[string]string
+		opts []maxim.Option
+	}{
+		"missing API key":   {},
+		"relative base URL": {opts: []maxim.Option{maxim.WithAPIKey("key"), maxim.WithBaseURL("app.getmaxim.ai")}},
+		"invalid debug":     {env: map[string]string{maxim.EnvAPIKey: "key", maxim.EnvDebug: "maybe"}},
+		"invalid interval":  {env: map[string]string{maxim.EnvAPIKey: "key", maxim.EnvFlushInterval: "soon"}},
+		"negative interval": {opts: []maxim.Option{maxim.WithAPIKey("key"), maxim.WithFlushInterval(-1)}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv(maxim.EnvAPIKey, "")
+			for key, value := range c.env {
+				t.Setenv(key, value)
+			}
+			if _, err := maxim.New(c.opts...); !errors.Is(err, maxim.ErrInvalidConfig) {
+				t.Errorf("expected ErrInvalidConfig, got %v", err)
+			}
+		})
+	}
+}
+
+func TestGetLoggerRequiresRepoId(t *testing.T) {
+	t.Setenv(maxim.EnvLogRepoId, "")
+	mx, err := maxim.New(maxim.WithAPIKey("key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := mx.GetLogger(&logging.LoggerConfig{}); !errors.Is(err, maxim.ErrInvalidConfig) {
+		t.Errorf("expected ErrInvalidConfig, got %v", err)
+	}
+}
+
+func TestGetLoggerCachesRepoCheck(t *testing.T) {
+	server := newFakeMaxim(t)
+	mx, err := maxim.New(maxim.WithAPIKey("key"), maxim.WithBaseURL(server.URL), maxim.WithLogRepoId("repo-1"), maxim.WithCredentialCheck())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mx.Cleanup()
+	for i := 0; i < 3; i++ {
+		if _, err := mx.GetLogger(nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := server.repoChecks.Load(); n != 1 {
+		t.Errorf("expected a single repository check, got %d", n)
+	}
+}
+
+func TestCredentialCheckFailure(t *testing.T) {
+	for name, c := range map[string]struct {
+		status int
+		body   string
+	}{
+		"error body":  {status: http.StatusOK, body: `{"error":{"message":"invalid api key"}}`},
+		"status only": {status: http.StatusForbidden, body: `forbidden`},
+	} {
+		t.Run(name, func(t *testing.T) {
+			server := newFakeMaxim(t)
+			server.repoStatus.Store(int32(c.status))
+			server.repoBody = c.body
+			_, err := maxim.New(maxim.WithAPIKey("key"), maxim.WithBaseURL(server.URL), maxim.WithLogRepoId("repo-1"), maxim.WithCredentialCheck())
+			if !errors.Is(err, maxim.ErrRepoNotFound) {
+				t.Errorf("expected ErrRepoNotFound, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package maxim_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/maximhq/maxim-go"
+	"github.com/maximhq/maxim-go/logging"
+)
+
+func TestGetLoggerConcurrent(t *testing.T) {
+	server := newFakeMaxim(t)
+	mx, err := maxim.New(maxim.WithAPIKey("key"), maxim.WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mx.Cleanup()
+	var wg sync.WaitGroup
+	loggers := make([]*logging.Logger, 32)
+	for i := range loggers {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			l, err := mx.GetLogger(&logging.LoggerConfig{Id: fmt.Sprintf("repo-%d", i%4)})
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			loggers[i] = l
+			mx.Loggers()
+		}(i)
+	}
+	wg.Wait()
+	for i, l := range loggers {
+		if l != loggers[i%4] {
+			t.Errorf("logger %d is not the one created for its repository", i)
+		}
+	}
+	if n := len(mx.Loggers()); n != 4 {
+		t.Errorf("expected 4 loggers, got %d", n)
+	}
+	if n := server.repoChecks.Load(); n != 4 {
+		t.Errorf("expected one repository check per repository, got %d", n)
+	}
+}
+
+func TestRemoveLogger(t *testing.T) {
+	server := newFakeMaxim(t)
+	mx, err := maxim.New(maxim.WithAPIKey("key"), maxim.WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mx.Cleanup()
+	first, err := mx.GetLogger(&logging.LoggerConfig{Id: "repo-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !mx.RemoveLogger("repo-1") {
+		t.Fatal("expected repo-1 to be removed")
+	}
+	if mx.RemoveLogger("repo-1") {
+		t.Error("expected repo-1 to be removed only once")
+	}
+	if _, ok := mx.Loggers()["repo-1"]; ok {
+		t.Error("removed logger is still registered")
+	}
+	second, err := mx.GetLogger(&logging.LoggerConfig{Id: "repo-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first == second {
+		t.Error("expected a new logger after removal")
+	}
+}
+
+func TestGetLoggerDoesNotWaitForOtherRepoChecks(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.RawQuery, "slow-repo") {
+			<-release
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+	defer close(release)
+	mx, err := maxim.New(maxim.WithAPIKey("key"), maxim.WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	go mx.GetLogger(&logging.LoggerConfig{Id: "slow-repo"})
+	time.Sleep(10 * time.Millisecond)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if _, err := mx.GetLogger(&logging.LoggerConfig{Id: "fast-repo"}); err != nil {
+			t.Error(err)
+		}
+		mx.Loggers()
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("GetLogger waited for the check of another repository")
+	}
+}
+
+func TestGetLoggerKeepsConfig(t *testing.T) {
+	server := newFakeMaxim(t)
+	mx, err := maxim.New(maxim.WithAPIKey("key"), maxim.WithBaseURL(server.URL), maxim.WithLogRepoId("repo-1"),
+		maxim.WithDebug(true), maxim.WithDefaultTags(map[string]string{"env": "prod"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mx.Cleanup()
+	config := &logging.LoggerConfig{DefaultTags: map[string]string{"team": "search"}}
+	if _, err := mx.GetLogger(config); err != nil {
+		t.Fatal(err)
+	}
+	if config.Id != "" || config.IsDebug || config.HTTPClient != nil || len(config.DefaultTags) != 1 {
+		t.Errorf("GetLogger modified the caller's config: %+v", config)
+	}
+}
@@ -1,5 +1,22 @@
 package maxim
 
+import (
+	"github.com/maximhq/maxim-go/internal"
+	"github.com/maximhq/maxim-go/logging"
+)
+
 func StrPtr(str string) *string {
 	return &str
 }
+
+// NewID returns a new entity id: a time-ordered (version 7) UUID. Entities
+// created with an empty Id get one automatically.
+func NewID() string {
+	return logging.NewID()
+}
+
+// NewUUIDv4 returns a random (version 4) UUID, for callers that prefer ids
+// that do not reveal their creation time.
+func NewUUIDv4() string {
+	return internal.NewUUIDv4()
+}
@@ -1,6 +1,10 @@
 package utils
 
+import "sync"
+
+// Queue is a FIFO queue safe for concurrent use.
 type Queue[T any] struct {
+	mutex   sync.Mutex
 	storage []T
 }
 
@@ -11,11 +15,27 @@ func NewQueue[T any]() *Queue[T] {
 
 // Enqueue adds an element to the end of the queue.
 func (q *Queue[T]) Enqueue(ele T) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
 	q.storage = append(q.storage, ele)
 }
 
+// EnqueueIfBelow adds an element to the end of the queue unless the queue
+// already holds max elements. A max of 0 or less means no limit.
+func (q *Queue[T]) EnqueueIfBelow(ele T, max int) bool {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if max > 0 && len(q.storage) >= max {
+		return false
+	}
+	q.storage = append(q.storage, ele)
+	return true
+}
+
 // Dequeue removes and returns the element at the front of the queue.
 func (q *Queue[T]) Dequeue() (T, bool) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
 	var zero T
 	if len(q.storage) == 0 {
 		return zero, false
@@ -25,8 +45,18 @@ func (q *Queue[T]) Dequeue() (T, bool) {
 	return element, true
 }
 
+// DequeueAll removes and returns all elements of the queue.
 func (q *Queue[T]) DequeueAll() []T {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
 	elements := q.storage
 	q.storage = nil
 	return elements
 }
+
+// Len returns the number of elements in the queue.
+func (q *Queue[T]) Len() int {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return len(q.storage)
+}